@@ -0,0 +1,30 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+)
+
+func runCommand(ctx context.Context, args []string, clients clients, services services) error {
+	switch args[0] {
+	case "scan":
+		fs := flag.NewFlagSet("scan", flag.ContinueOnError)
+		publish := fs.Bool("publish", false, "Publish results to the AMQP exchange instead of printing them")
+
+		if err := fs.Parse(args[1:]); err != nil {
+			return fmt.Errorf("parse flags: %w", err)
+		}
+
+		if fs.NArg() < 1 {
+			return errors.New("usage: exas [flags] scan [--publish] <dir>")
+		}
+
+		return services.exas.Scan(ctx, fs.Arg(0), nil, *publish, false, os.Stdout)
+
+	default:
+		return fmt.Errorf("unknown command `%s`", args[0])
+	}
+}
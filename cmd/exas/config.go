@@ -32,6 +32,9 @@ type configuration struct {
 	geocode     *geocode.Config
 	amqp        *amqp.Config
 	amqphandler *amqphandler.Config
+	amqpUpdate  *amqphandler.Config
+
+	args []string
 }
 
 func newConfig() configuration {
@@ -52,9 +55,12 @@ func newConfig() configuration {
 		geocode:     geocode.Flags(fs, ""),
 		amqp:        amqp.Flags(fs, "amqp"),
 		amqphandler: amqphandler.Flags(fs, "amqp", flags.NewOverride("Exchange", "fibr"), flags.NewOverride("Queue", "exas"), flags.NewOverride("RoutingKey", "exif_input")),
+		amqpUpdate:  amqphandler.Flags(fs, "amqpUpdate", flags.NewOverride("Exchange", "fibr"), flags.NewOverride("Queue", "exas-update"), flags.NewOverride("RoutingKey", "exif_update")),
 	}
 
 	_ = fs.Parse(os.Args[1:])
 
+	config.args = fs.Args()
+
 	return config
 }
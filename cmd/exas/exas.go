@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"log/slog"
 
 	"github.com/ViBiOh/httputils/v4/pkg/alcotest"
 	"github.com/ViBiOh/httputils/v4/pkg/health"
@@ -26,13 +27,24 @@ func main() {
 	services, err := newServices(config, clients, adapters)
 	logger.FatalfOnErr(ctx, err, "services")
 
-	go services.Start(clients.health.DoneCtx())
 	defer services.Close()
 
+	if len(config.args) != 0 {
+		go services.exas.Start(ctx)
+
+		if err = runCommand(ctx, config.args, clients, services); err != nil {
+			slog.LogAttrs(ctx, slog.LevelError, "command", slog.Any("error", err))
+		}
+
+		return
+	}
+
+	go services.Start(clients.health.DoneCtx())
+
 	port := newPort(clients, services)
 
 	go services.server.Start(clients.health.EndCtx(), port)
 
 	clients.health.WaitForTermination(services.server.Done())
-	health.WaitAll(services.server.Done(), services.amqphandler.Done())
+	health.WaitAll(services.server.Done(), services.amqphandler.Done(), services.amqpUpdate.Done())
 }
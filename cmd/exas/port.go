@@ -11,6 +11,13 @@ func newPort(clients clients, services services) http.Handler {
 
 	mux.HandleFunc("GET /", services.exas.HandleGet)
 	mux.HandleFunc("POST /", services.exas.HandlePost)
+	mux.HandleFunc("PATCH /", services.exas.HandlePatch)
+	mux.HandleFunc("POST /batch", services.exas.HandleBatch)
+	mux.HandleFunc("POST /strip", services.exas.HandleStrip)
+	mux.HandleFunc("GET /scan/{dir...}", services.exas.HandleScan)
+	mux.HandleFunc("GET /preview/{path...}", services.exas.HandlePreview)
+	mux.HandleFunc("POST /preview", services.exas.HandlePostPreview)
+	mux.HandleFunc("GET /geocode/search", services.exas.HandleSearch)
 
 	return httputils.Handler(
 		mux, clients.health,
@@ -13,6 +13,7 @@ import (
 type services struct {
 	server      *server.Server
 	amqphandler *amqphandler.Service
+	amqpUpdate  *amqphandler.Service
 	exas        exas.Service
 	geocode     geocode.Service
 }
@@ -23,21 +24,35 @@ func newServices(config configuration, clients clients, adapters adapters) (serv
 
 	output.server = server.New(config.server)
 
-	output.geocode = geocode.New(config.geocode, clients.telemetry.MeterProvider(), clients.telemetry.TracerProvider())
-	output.exas = exas.New(config.exas, output.geocode, clients.amqp, adapters.storage, clients.telemetry.MeterProvider(), clients.telemetry.TracerProvider())
+	output.geocode, err = geocode.New(config.geocode, adapters.storage, clients.telemetry.MeterProvider(), clients.telemetry.TracerProvider())
+	if err != nil {
+		return output, fmt.Errorf("geocode: %w", err)
+	}
+
+	output.exas, err = exas.New(config.exas, output.geocode, clients.amqp, adapters.storage, clients.telemetry.MeterProvider(), clients.telemetry.TracerProvider())
+	if err != nil {
+		return output, fmt.Errorf("exas: %w", err)
+	}
 
 	output.amqphandler, err = amqphandler.New(config.amqphandler, clients.amqp, clients.telemetry.MeterProvider(), clients.telemetry.TracerProvider(), output.exas.AmqpHandler)
 	if err != nil {
 		return output, fmt.Errorf("amqphandler: %w", err)
 	}
 
+	output.amqpUpdate, err = amqphandler.New(config.amqpUpdate, clients.amqp, clients.telemetry.MeterProvider(), clients.telemetry.TracerProvider(), output.exas.AmqpUpdateHandler)
+	if err != nil {
+		return output, fmt.Errorf("amqp update handler: %w", err)
+	}
+
 	return output, nil
 }
 
 func (s services) Start(ctx context.Context) {
+	go s.exas.Start(ctx)
 	go s.amqphandler.Start(ctx)
+	go s.amqpUpdate.Start(ctx)
 }
 
 func (s services) Close() {
-	s.geocode.Close()
+	s.exas.Close()
 }
@@ -12,6 +12,12 @@ import (
 	amqp "github.com/rabbitmq/amqp091-go"
 )
 
+// amqpRequest is an absto.Item with extraction options
+type amqpRequest struct {
+	absto.Item
+	rawOptions
+}
+
 type amqpResponse struct {
 	Exif model.Exif `json:"exif"`
 	Item absto.Item `json:"item"`
@@ -34,24 +40,28 @@ func (s Service) AmqpHandler(ctx context.Context, message amqp.Delivery) (err er
 	ctx, end := telemetry.StartSpan(ctx, s.tracer, "amqp")
 	defer end(&err)
 
-	var item absto.Item
-	if err = json.Unmarshal(message.Body, &item); err != nil {
+	var request amqpRequest
+	if err = json.Unmarshal(message.Body, &request); err != nil {
 		return errors.Join(fmt.Errorf("decode: %w", err), errUnmarshal)
 	}
 
-	reader, err := s.storage.ReadFrom(ctx, item.Pathname)
+	opts, err := request.options()
 	if err != nil {
-		return fmt.Errorf("read from storage: %w", err)
+		return errors.Join(fmt.Errorf("options: %w", err), errUnmarshal)
 	}
-	defer closeWithLog(ctx, reader, "AmqpHandler", item.Pathname)
 
 	var exif model.Exif
-	exif, err = s.get(ctx, reader)
-	if err != nil {
+	// unsupported files are still answered, with empty metadata, as fibr waits for a response of each file
+	exif, err = s.getItem(ctx, request.Item, opts)
+	if err != nil && !errors.Is(err, errUnsupported) {
 		return errors.Join(fmt.Errorf("get exif: %w", err), errExtract)
 	}
 
-	if err = s.amqpClient.PublishJSON(ctx, amqpResponse{Item: item, Exif: exif}, s.amqpExchange, s.amqpRoutingKey); err != nil {
+	return s.publish(ctx, request.Item, exif)
+}
+
+func (s Service) publish(ctx context.Context, item absto.Item, exif model.Exif) error {
+	if err := s.amqpClient.PublishJSON(ctx, amqpResponse{Item: item, Exif: exif}, s.amqpExchange, s.amqpRoutingKey); err != nil {
 		return errors.Join(fmt.Errorf("publish amqp message: %w", err), errPublish)
 	}
 
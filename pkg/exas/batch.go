@@ -0,0 +1,68 @@
+package exas
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"github.com/ViBiOh/httputils/v4/pkg/concurrent"
+	"github.com/ViBiOh/httputils/v4/pkg/httpjson"
+)
+
+func (s Service) HandleBatch(w http.ResponseWriter, r *http.Request) {
+	if !s.storage.Enabled() {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	ctx := r.Context()
+
+	defer closeWithLog(ctx, r.Body, "HandleBatch", "input")
+
+	pathnames, err := httpjson.Parse[[]string](r)
+	if err != nil {
+		s.httpError(ctx, w, "batch", fmt.Errorf("parse pathnames: %w: %w", errUnmarshal, err))
+		return
+	}
+
+	if s.batchMaxSize > 0 && len(pathnames) > s.batchMaxSize {
+		s.httpError(ctx, w, "batch", fmt.Errorf("%d pathnames above %d: %w", len(pathnames), s.batchMaxSize, errTooManyItems))
+		return
+	}
+
+	opts, err := requestOptions(r)
+	if err != nil {
+		s.httpError(ctx, w, "batch", err)
+		return
+	}
+
+	writer := newNDJSONWriter(w)
+	limiter := concurrent.NewLimiter(s.batchConcurrency)
+
+	for _, pathname := range pathnames {
+		if ctx.Err() != nil {
+			break
+		}
+
+		limiter.Go(func() {
+			response := itemResponse{Pathname: pathname}
+
+			exif, err := s.getFromStorage(ctx, pathname, opts)
+			if err != nil {
+				slog.LogAttrs(ctx, slog.LevelError, "batch item", slog.String("item", pathname), slog.Any("error", err))
+				status, code := errorCategory(err)
+				response.Error, response.Code = errorMessage(err, status, code), code
+				s.increaseMetric(ctx, "batch", "exif", response.Code)
+			} else {
+				response.Exif = &exif
+				s.increaseMetric(ctx, "batch", "exif", "success")
+			}
+
+			if err := writer.Write(response); err != nil {
+				slog.LogAttrs(ctx, slog.LevelError, "write batch item", slog.String("item", pathname), slog.Any("error", err))
+			}
+		})
+	}
+
+	limiter.Wait()
+}
@@ -0,0 +1,101 @@
+package exas
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/ViBiOh/absto/pkg/filesystem"
+)
+
+func TestHandleBatch(t *testing.T) {
+	t.Parallel()
+
+	storage, err := filesystem.New(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	type args struct {
+		body    string
+		maxSize int
+	}
+
+	cases := map[string]struct {
+		args       args
+		want       []string
+		wantStatus int
+	}{
+		"invalid payload": {
+			args{
+				body: `{"pathname": "/image.jpg"}`,
+			},
+			[]string{`"code":"unmarshal_error"`},
+			http.StatusBadRequest,
+		},
+		"too many": {
+			args{
+				body:    `["/a.jpg", "/b.jpg", "/c.jpg"]`,
+				maxSize: 2,
+			},
+			[]string{`"code":"too_many_items"`},
+			http.StatusBadRequest,
+		},
+		"not found": {
+			args{
+				body:    `["/a.jpg", "/b.jpg"]`,
+				maxSize: 2,
+			},
+			[]string{
+				`{"pathname":"/a.jpg","error":"stat from storage: `,
+				`{"pathname":"/b.jpg","error":"stat from storage: `,
+			},
+			http.StatusOK,
+		},
+		"no limit": {
+			args{
+				body: `["/a.jpg", "/b.jpg", "/c.jpg"]`,
+			},
+			[]string{
+				`{"pathname":"/a.jpg","error":"stat from storage: `,
+				`{"pathname":"/b.jpg","error":"stat from storage: `,
+				`{"pathname":"/c.jpg","error":"stat from storage: `,
+			},
+			http.StatusOK,
+		},
+	}
+
+	for intention, tc := range cases {
+		t.Run(intention, func(t *testing.T) {
+			t.Parallel()
+
+			service := Service{storage: storage, batchMaxSize: tc.args.maxSize}
+
+			writer := httptest.NewRecorder()
+			service.HandleBatch(writer, httptest.NewRequest(http.MethodPost, "/batch", strings.NewReader(tc.args.body)))
+
+			lines := strings.Split(strings.TrimSpace(writer.Body.String()), "\n")
+			slices.Sort(lines)
+
+			if writer.Code != tc.wantStatus {
+				t.Errorf("HandleBatch() status = %d, want %d", writer.Code, tc.wantStatus)
+			}
+
+			if len(lines) != len(tc.want) {
+				t.Fatalf("HandleBatch() = `%s`, want %d lines", writer.Body.String(), len(tc.want))
+			}
+
+			for index, want := range tc.want {
+				if !strings.Contains(lines[index], want) {
+					t.Errorf("HandleBatch() line #%d = `%s`, want `%s`", index, lines[index], want)
+				}
+			}
+
+			if tc.wantStatus == http.StatusOK && !strings.Contains(writer.Body.String(), `"code":"not_found"`) {
+				t.Errorf("HandleBatch() = `%s`, want not_found code", writer.Body.String())
+			}
+		})
+	}
+}
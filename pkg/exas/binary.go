@@ -0,0 +1,81 @@
+package exas
+
+import (
+	"context"
+	"encoding/base64"
+	"log/slog"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const base64Prefix = "base64:"
+
+var binarySizeRegex = regexp.MustCompile(`^\(Binary data (\d+) bytes`)
+
+// binarySize reads the size of a `(Binary data 1234 bytes, use -b option to extract)` placeholder of exiftool
+func binarySize(value any) (int, bool) {
+	content, _ := value.(string)
+
+	matches := binarySizeRegex.FindStringSubmatch(content)
+	if len(matches) == 0 {
+		return 0, false
+	}
+
+	size, err := strconv.Atoi(matches[1])
+	if err != nil {
+		return 0, false
+	}
+
+	return size, true
+}
+
+// binaryTags filters the requested tags already known to be above the max size. Tags without placeholder are kept, e.g. the ICC_Profile group.
+func (s Service) binaryTags(ctx context.Context, data map[string]any, requested []string) []string {
+	var output []string
+
+	for _, tag := range requested {
+		if size, ok := binarySize(data[tag]); ok && size > s.binaryMaxSize {
+			slog.LogAttrs(ctx, slog.LevelDebug, "binary tag too large", slog.String("tag", tag), slog.Int("size", size))
+			continue
+		}
+
+		output = append(output, tag)
+	}
+
+	return output
+}
+
+// readBinary adds the content of binary tags to the data, base64 encoded by exiftool, with their group when the data has
+func (s Service) readBinary(ctx context.Context, name string, tags []string, data map[string]any, groups bool) error {
+	args := []string{"-json", "-b"}
+	if groups {
+		args = append(args, "-G1")
+	}
+	for _, tag := range tags {
+		args = append(args, "-"+tag)
+	}
+
+	values, err := s.runTags(ctx, append(args, name)...)
+	if err != nil {
+		return err
+	}
+
+	for key, value := range values {
+		content, ok := value.(string)
+		if !ok || !strings.HasPrefix(content, base64Prefix) {
+			continue
+		}
+
+		content = strings.TrimPrefix(content, base64Prefix)
+
+		if size := base64.StdEncoding.DecodedLen(len(content)); size > s.binaryMaxSize {
+			slog.LogAttrs(ctx, slog.LevelDebug, "binary tag too large", slog.String("tag", key), slog.Int("size", size))
+			continue
+		}
+
+		data[key] = content
+	}
+
+	return nil
+}
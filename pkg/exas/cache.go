@@ -0,0 +1,158 @@
+package exas
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"path"
+	"strings"
+	"sync"
+
+	absto "github.com/ViBiOh/absto/pkg/model"
+	"github.com/ViBiOh/exas/pkg/lru"
+	"github.com/ViBiOh/exas/pkg/model"
+)
+
+const (
+	cacheMemory  = "memory"
+	cacheStorage = "storage"
+)
+
+type cache interface {
+	Get(ctx context.Context, key string) (model.Exif, bool, error)
+	Set(ctx context.Context, key string, exif model.Exif) error
+}
+
+func newCache(config *Config, storage absto.Storage) (cache, error) {
+	switch config.CacheType {
+	case "":
+		return nil, nil
+	case cacheMemory:
+		if config.CacheSize == 0 {
+			return nil, nil
+		}
+
+		return memoryCache{lru.New[string, model.Exif](int(config.CacheSize))}, nil
+	case cacheStorage:
+		if !storage.Enabled() {
+			return nil, fmt.Errorf("`%s` cache requires a storage", cacheStorage)
+		}
+
+		return &storageCache{storage: storage, directory: absto.Dirname(config.CacheDirectory)}, nil
+	default:
+		return nil, fmt.Errorf("unknown cache type `%s`", config.CacheType)
+	}
+}
+
+// isCacheItem tells if the item is a sidecar of the storage cache, never extracted itself
+func (s Service) isCacheItem(item absto.Item) bool {
+	storage, ok := s.cache.(*storageCache)
+
+	return ok && strings.HasPrefix(item.Pathname, storage.directory)
+}
+
+func itemCacheKey(item absto.Item) string {
+	return "item-" + absto.ID(item.String())
+}
+
+func contentCacheKey(hash []byte) string {
+	return fmt.Sprintf("content-%x", hash)
+}
+
+func (s Service) getCache(ctx context.Context, key string, refresh bool) (model.Exif, bool) {
+	if s.cache == nil || refresh {
+		return model.Exif{}, false
+	}
+
+	exif, ok, err := s.cache.Get(ctx, key)
+	if err != nil {
+		slog.LogAttrs(ctx, slog.LevelError, "get from cache", slog.String("key", key), slog.Any("error", err))
+	}
+
+	if ok {
+		s.increaseMetric(ctx, "cache", "exif", "hit")
+	} else {
+		s.increaseMetric(ctx, "cache", "exif", "miss")
+	}
+
+	return exif, ok
+}
+
+func (s Service) setCache(ctx context.Context, key string, exif model.Exif) {
+	if s.cache == nil || len(key) == 0 {
+		return
+	}
+
+	if err := s.cache.Set(ctx, key, exif); err != nil {
+		slog.LogAttrs(ctx, slog.LevelError, "set to cache", slog.String("key", key), slog.Any("error", err))
+	}
+}
+
+type memoryCache struct {
+	content *lru.Cache[string, model.Exif]
+}
+
+func (m memoryCache) Get(_ context.Context, key string) (model.Exif, bool, error) {
+	exif, ok := m.content.Get(key)
+
+	return exif, ok, nil
+}
+
+func (m memoryCache) Set(_ context.Context, key string, exif model.Exif) error {
+	m.content.Set(key, exif)
+
+	return nil
+}
+
+type storageCache struct {
+	storage   absto.Storage
+	directory string
+	mkdir     sync.Once
+}
+
+func (s *storageCache) filename(key string) string {
+	return path.Join(s.directory, key+".json")
+}
+
+func (s *storageCache) Get(ctx context.Context, key string) (exif model.Exif, ok bool, err error) {
+	reader, err := s.storage.ReadFrom(ctx, s.filename(key))
+	if err != nil {
+		if absto.IsNotExist(err) {
+			return exif, false, nil
+		}
+
+		return exif, false, fmt.Errorf("read: %w", err)
+	}
+	defer closeWithLog(ctx, reader, "storageCache.Get", key)
+
+	if err = json.NewDecoder(reader).Decode(&exif); err != nil {
+		return exif, false, fmt.Errorf("decode: %w", err)
+	}
+
+	return exif, true, nil
+}
+
+func (s *storageCache) Set(ctx context.Context, key string, exif model.Exif) error {
+	var err error
+
+	s.mkdir.Do(func() {
+		err = s.storage.Mkdir(ctx, s.directory, absto.DirectoryPerm)
+	})
+
+	if err != nil {
+		return fmt.Errorf("mkdir: %w", err)
+	}
+
+	payload, err := json.Marshal(exif)
+	if err != nil {
+		return fmt.Errorf("encode: %w", err)
+	}
+
+	if err = s.storage.WriteTo(ctx, s.filename(key), bytes.NewReader(payload), absto.WriteOpts{Size: int64(len(payload))}); err != nil {
+		return fmt.Errorf("write: %w", err)
+	}
+
+	return nil
+}
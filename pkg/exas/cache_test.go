@@ -0,0 +1,214 @@
+package exas
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ViBiOh/absto/pkg/filesystem"
+	absto "github.com/ViBiOh/absto/pkg/model"
+	"github.com/ViBiOh/exas/pkg/model"
+)
+
+func TestNewCache(t *testing.T) {
+	t.Parallel()
+
+	type args struct {
+		config Config
+	}
+
+	cases := map[string]struct {
+		args    args
+		want    bool
+		wantErr bool
+	}{
+		"disabled": {
+			args{
+				config: Config{CacheSize: 10},
+			},
+			false,
+			false,
+		},
+		"memory": {
+			args{
+				config: Config{CacheType: cacheMemory, CacheSize: 10},
+			},
+			true,
+			false,
+		},
+		"empty memory": {
+			args{
+				config: Config{CacheType: cacheMemory},
+			},
+			false,
+			false,
+		},
+		"unknown": {
+			args{
+				config: Config{CacheType: "redis"},
+			},
+			false,
+			true,
+		},
+	}
+
+	for intention, tc := range cases {
+		t.Run(intention, func(t *testing.T) {
+			t.Parallel()
+
+			got, gotErr := newCache(&tc.args.config, nil)
+
+			if (gotErr != nil) != tc.wantErr {
+				t.Errorf("newCache() error = %v, want error %t", gotErr, tc.wantErr)
+			} else if (got != nil) != tc.want {
+				t.Errorf("newCache() = %v, want cache %t", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestCacheKey(t *testing.T) {
+	t.Parallel()
+
+	date := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	item := absto.Item{Pathname: "/image.jpg", SizeValue: 1024, Date: date}
+	reference := options{}.cacheKey(itemCacheKey(item))
+
+	type args struct {
+		item absto.Item
+		opts options
+	}
+
+	cases := map[string]struct {
+		args args
+		want bool
+	}{
+		"same": {
+			args{
+				item: item,
+			},
+			true,
+		},
+		"refresh": {
+			args{
+				item: item,
+				opts: options{refresh: true},
+			},
+			true,
+		},
+		"pathname": {
+			args{
+				item: absto.Item{Pathname: "/other.jpg", SizeValue: 1024, Date: date},
+			},
+			false,
+		},
+		"date": {
+			args{
+				item: absto.Item{Pathname: "/image.jpg", SizeValue: 1024, Date: date.Add(time.Second)},
+			},
+			false,
+		},
+		"size": {
+			args{
+				item: absto.Item{Pathname: "/image.jpg", SizeValue: 2048, Date: date},
+			},
+			false,
+		},
+		"language": {
+			args{
+				item: item,
+				opts: options{language: "fr"},
+			},
+			false,
+		},
+		"binary": {
+			args{
+				item: item,
+				opts: options{binary: []string{"ThumbnailImage"}},
+			},
+			false,
+		},
+		"tags": {
+			args{
+				item: item,
+				opts: options{tags: []string{"Make"}},
+			},
+			false,
+		},
+		"numeric": {
+			args{
+				item: item,
+				opts: options{numeric: true},
+			},
+			false,
+		},
+	}
+
+	for intention, tc := range cases {
+		t.Run(intention, func(t *testing.T) {
+			t.Parallel()
+
+			got := tc.args.opts.cacheKey(itemCacheKey(tc.args.item))
+
+			if (got == reference) != tc.want {
+				t.Errorf("cacheKey() = `%s`, reference `%s`, want same %t", got, reference, tc.want)
+			}
+		})
+	}
+}
+
+func TestStorageCache(t *testing.T) {
+	t.Parallel()
+
+	directory := t.TempDir()
+
+	storage, err := filesystem.New(directory)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cache := &storageCache{storage: storage, directory: "/.exas/"}
+	ctx := context.Background()
+
+	exif := model.Exif{
+		Date: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		Data: map[string]any{"Make": "Canon"},
+	}
+
+	if _, ok, err := cache.Get(ctx, "missing"); ok || err != nil {
+		t.Errorf("Get(`missing`) = (%t, %v), want (false, nil)", ok, err)
+	}
+
+	if err := cache.Set(ctx, "item", exif); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+
+	got, ok, err := cache.Get(ctx, "item")
+	if !ok || err != nil {
+		t.Errorf("Get(`item`) = (%t, %v), want (true, nil)", ok, err)
+	} else if !reflect.DeepEqual(got, exif) {
+		t.Errorf("Get(`item`) = %+v, want %+v", got, exif)
+	}
+
+	if err := os.WriteFile(filepath.Join(directory, ".exas", "corrupt.json"), []byte("{"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok, err := cache.Get(ctx, "corrupt"); ok || err == nil || !strings.HasPrefix(err.Error(), "decode") {
+		t.Errorf("Get(`corrupt`) = (%t, %v), want decode error", ok, err)
+	}
+
+	service := Service{cache: cache}
+
+	if !service.isCacheItem(absto.Item{Pathname: "/.exas/item.json"}) {
+		t.Error("isCacheItem(`/.exas/item.json`) = false, want true")
+	}
+
+	if service.isCacheItem(absto.Item{Pathname: "/image.jpg"}) {
+		t.Error("isCacheItem(`/image.jpg`) = true, want false")
+	}
+}
@@ -1,18 +1,39 @@
 package exas
 
 import (
+	"context"
+	"math"
 	"time"
 
+	"github.com/ViBiOh/exas/pkg/geocode"
 	"github.com/ViBiOh/exas/pkg/model"
 )
 
 const (
 	offsetTimeName = "OffsetTime"
 	tzPattern      = "2006:01:02 15:04:05Z07:00"
+	offsetPattern  = "-07:00"
+)
+
+// dateOffset tells how the offset of a parsed date is known
+type dateOffset int
+
+const (
+	offsetUnknown dateOffset = iota // wall clock of an unknown zone, parsed in UTC
+	offsetKnown                     // written by the device
+	offsetUTC                       // instant in UTC by specification, e.g. QuickTime dates
 )
 
 var (
-	exifDates = []string{
+	// quickTimeDates are in UTC for video containers
+	quickTimeDates = []string{
+		"CreateDate",
+		"ModifyDate",
+		"MediaCreateDate",
+		"TrackCreateDate",
+	}
+
+	defaultExifDates = []string{
 		"GPSDateTime",
 		"SubSecDateTimeOriginal",
 		"SubSecCreateDate",
@@ -22,52 +43,116 @@ var (
 		"CreateDate",
 	}
 
-	datePatterns = []string{
+	defaultDatePatterns = []string{
 		"2006:01:02 15:04:05",
 		"2006:01:02",
 	}
 )
 
-func getDate(exif model.Exif) time.Time {
-	var dates []string
+// dateParser reads the creation date from the first tag of `tags` having an offset, or else matching the first of `patterns`
+type dateParser struct {
+	tags     []string
+	patterns []string
+}
 
-	for _, exifDate := range exifDates {
-		if date := getExifString(exif, exifDate); len(date) > 0 {
-			dates = append(dates, date)
+// parse fills the date of the Exif, its source tag and the candidate dates. It returns how the offset of the date is known.
+func (p dateParser) parse(exif model.Exif) (model.Exif, dateOffset) {
+	offsetTime := getExifString(exif, offsetTimeName)
+
+	chosen := -1
+	bestRank := math.MaxInt
+
+	for _, tag := range p.tags {
+		raw := getExifString(exif, tag)
+		if len(raw) == 0 {
+			continue
+		}
+
+		date, rank := p.parseValue(raw, offsetTime)
+
+		exif.DateCandidates = append(exif.DateCandidates, model.DateCandidate{
+			Tag:  tag,
+			Raw:  raw,
+			Date: date,
+		})
+
+		if !date.IsZero() && rank < bestRank {
+			chosen = len(exif.DateCandidates) - 1
+			bestRank = rank
 		}
 	}
 
-	if createDate := parseDateWithTimezone(exif, dates); !createDate.IsZero() {
-		return createDate
+	if chosen == -1 {
+		return exif, offsetUnknown
 	}
 
-	for _, pattern := range datePatterns {
-		for _, date := range dates {
-			if createDate, err := time.Parse(pattern, date); err == nil {
-				return createDate
-			}
-		}
+	exif.Date = exif.DateCandidates[chosen].Date
+	exif.DateSource = exif.DateCandidates[chosen].Tag
+
+	if bestRank < 2 {
+		return exif, offsetKnown
 	}
 
-	return time.Time{}
+	return exif, offsetUnknown
 }
 
-func parseDateWithTimezone(exif model.Exif, dates []string) time.Time {
-	for _, date := range dates {
-		if createDate, err := time.Parse(tzPattern, date); err == nil {
-			return createDate
+// parseValue returns the date and its rank: 0 with an offset in the value, 1 with the offset of OffsetTime, then the index of the matching pattern
+func (p dateParser) parseValue(raw, offsetTime string) (time.Time, int) {
+	if date, err := time.Parse(tzPattern, raw); err == nil {
+		return date, 0
+	}
+
+	if len(offsetTime) != 0 {
+		if date, err := time.Parse(tzPattern, raw+offsetTime); err == nil {
+			return date, 1
 		}
 	}
 
-	if offsetTime := getExifString(exif, offsetTimeName); len(offsetTime) > 0 {
-		for _, date := range dates {
-			if createDate, err := time.Parse(tzPattern, date+offsetTime); err == nil {
-				return createDate
-			}
+	for index, pattern := range p.patterns {
+		if date, err := time.Parse(pattern, raw); err == nil {
+			return date, index + 2
+		}
+	}
+
+	return time.Time{}, 0
+}
+
+// localizeDate moves a date without offset or in UTC to the timezone of the coordinates, if any, and records where the offset came from.
+// The date is left untouched when no zone is found for the coordinates.
+func (s Service) localizeDate(ctx context.Context, exif model.Exif, offset dateOffset) model.Exif {
+	if exif.Date.IsZero() {
+		return exif
+	}
+
+	var location *time.Location
+	var source string
+
+	if offset != offsetKnown && exif.Geocode.HasCoordinates() {
+		location, source = s.geocode.Timezone(ctx, exif.Geocode.Latitude, exif.Geocode.Longitude)
+	}
+
+	switch {
+	case offset == offsetKnown:
+		exif.OffsetSource = geocode.TimezoneSourceExif
+	case location != nil:
+		exif.OffsetSource = source
+		exif.Timezone = location.String()
+
+		if offset == offsetUTC {
+			exif.Date = exif.Date.In(location)
+		} else {
+			year, month, day := exif.Date.Date()
+			exif.Date = time.Date(year, month, day, exif.Date.Hour(), exif.Date.Minute(), exif.Date.Second(), exif.Date.Nanosecond(), location)
 		}
+	case offset == offsetUTC:
+		exif.OffsetSource = geocode.TimezoneSourceExif
+	default:
+		exif.OffsetSource = geocode.TimezoneSourceFallback
 	}
 
-	return time.Time{}
+	exif.DateOffset = exif.Date.Format(offsetPattern)
+
+	return exif
 }
 
 func getExifString(exif model.Exif, key string) string {
@@ -0,0 +1,167 @@
+package exas
+
+import (
+	"context"
+	"flag"
+	"testing"
+	"time"
+
+	"github.com/ViBiOh/exas/pkg/geocode"
+	"github.com/ViBiOh/exas/pkg/model"
+)
+
+func TestDateParserParse(t *testing.T) {
+	t.Parallel()
+
+	parser := dateParser{tags: defaultExifDates, patterns: defaultDatePatterns}
+
+	cases := map[string]struct {
+		args          map[string]any
+		want          time.Time
+		wantSource    string
+		wantOffset    dateOffset
+		wantCandidate int
+	}{
+		"offset time": {
+			map[string]any{
+				"DateTimeOriginal": "2024:06:01 10:00:00",
+				"CreateDate":       "2024:06:01 09:00:00",
+				"OffsetTime":       "+02:00",
+			},
+			time.Date(2024, 6, 1, 10, 0, 0, 0, time.FixedZone("", 2*3600)),
+			"DateTimeOriginal",
+			offsetKnown,
+			2,
+		},
+		"zoned over priority": {
+			map[string]any{
+				"DateTimeOriginal": "2024:06:01 10:00:00",
+				"CreationDate":     "2024:06:01 10:00:00+02:00",
+			},
+			time.Date(2024, 6, 1, 10, 0, 0, 0, time.FixedZone("", 2*3600)),
+			"CreationDate",
+			offsetKnown,
+			2,
+		},
+		"pattern over priority": {
+			map[string]any{
+				"DateCreated":      "2024:06:01",
+				"DateTimeOriginal": "2024:06:01 10:00:00",
+			},
+			time.Date(2024, 6, 1, 10, 0, 0, 0, time.UTC),
+			"DateTimeOriginal",
+			offsetUnknown,
+			2,
+		},
+		"unparsable": {
+			map[string]any{
+				"CreateDate": "0000:00:00 00:00:00",
+			},
+			time.Time{},
+			"",
+			offsetUnknown,
+			1,
+		},
+	}
+
+	for intention, tc := range cases {
+		t.Run(intention, func(t *testing.T) {
+			t.Parallel()
+
+			got, gotOffset := parser.parse(model.Exif{Data: tc.args})
+
+			if got.Date.Format(time.RFC3339) != tc.want.Format(time.RFC3339) {
+				t.Errorf("parse() date = %s, want %s", got.Date, tc.want)
+			}
+
+			if got.DateSource != tc.wantSource {
+				t.Errorf("parse() source = `%s`, want `%s`", got.DateSource, tc.wantSource)
+			}
+
+			if gotOffset != tc.wantOffset {
+				t.Errorf("parse() offset = %d, want %d", gotOffset, tc.wantOffset)
+			}
+
+			if len(got.DateCandidates) != tc.wantCandidate {
+				t.Errorf("parse() candidates = %d, want %d", len(got.DateCandidates), tc.wantCandidate)
+			}
+		})
+	}
+}
+
+func TestLocalizeDate(t *testing.T) {
+	t.Parallel()
+
+	date := time.Date(2024, 6, 1, 10, 0, 0, 0, time.UTC)
+	coordinates := model.Geocode{Latitude: -45, Longitude: 170}
+
+	type args struct {
+		exif   model.Exif
+		offset dateOffset
+	}
+
+	cases := map[string]struct {
+		args       args
+		wantSource string
+	}{
+		"known offset": {
+			args{
+				exif:   model.Exif{Date: date, Geocode: coordinates},
+				offset: offsetKnown,
+			},
+			geocode.TimezoneSourceExif,
+		},
+		"no zone for coordinates": {
+			args{
+				exif:   model.Exif{Date: date, Geocode: coordinates},
+				offset: offsetUnknown,
+			},
+			geocode.TimezoneSourceFallback,
+		},
+		"utc without zone": {
+			args{
+				exif:   model.Exif{Date: date, Geocode: coordinates},
+				offset: offsetUTC,
+			},
+			geocode.TimezoneSourceExif,
+		},
+	}
+
+	for intention, tc := range cases {
+		t.Run(intention, func(t *testing.T) {
+			t.Parallel()
+
+			got := Service{}.localizeDate(context.Background(), tc.args.exif, tc.args.offset)
+
+			if !got.Date.Equal(date) || got.DateOffset != "+00:00" || len(got.Timezone) != 0 {
+				t.Errorf("localizeDate() = %s `%s` `%s`, want untouched %s", got.Date, got.DateOffset, got.Timezone, date)
+			} else if got.OffsetSource != tc.wantSource {
+				t.Errorf("localizeDate() source = `%s`, want `%s`", got.OffsetSource, tc.wantSource)
+			}
+		})
+	}
+}
+
+func TestLocalizeDateDefault(t *testing.T) {
+	t.Parallel()
+
+	geocodeService, err := geocode.New(geocode.Flags(flag.NewFlagSet("test", flag.ContinueOnError), ""), nil, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	exif := model.Exif{
+		Date:    time.Date(2024, 6, 1, 10, 0, 0, 0, time.UTC),
+		Geocode: model.Geocode{Latitude: 48.858370, Longitude: 2.294481},
+	}
+
+	got := Service{geocode: geocodeService}.localizeDate(context.Background(), exif, offsetUnknown)
+
+	if got.Timezone != "Europe/Paris" || got.OffsetSource != geocode.TimezoneSourceGPS {
+		t.Errorf("localizeDate() = `%s` from `%s`, want `Europe/Paris` from `%s`", got.Timezone, got.OffsetSource, geocode.TimezoneSourceGPS)
+	}
+
+	if want := time.Date(2024, 6, 1, 8, 0, 0, 0, time.UTC); !got.Date.Equal(want) || got.DateOffset != "+02:00" {
+		t.Errorf("localizeDate() = %s `%s`, want %s `+02:00`", got.Date, got.DateOffset, want)
+	}
+}
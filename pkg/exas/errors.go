@@ -0,0 +1,118 @@
+package exas
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	absto "github.com/ViBiOh/absto/pkg/model"
+	"github.com/ViBiOh/exas/pkg/geocode"
+	"github.com/ViBiOh/exas/pkg/model"
+	"github.com/ViBiOh/httputils/v4/pkg/httperror"
+	"github.com/ViBiOh/httputils/v4/pkg/httpjson"
+)
+
+const (
+	internalErrorCode = "error"
+
+	// providerErrorMessage is responded instead of the details of the geocoding provider, which can hold the API key or the coordinates of the request
+	providerErrorMessage = "geocoding provider failed"
+)
+
+var (
+	errNotFound     = errors.New("not found")
+	errTooLarge     = errors.New("payload too large")
+	errUnsupported  = errors.New("unsupported file type")
+	errCorrupt      = errors.New("corrupt file")
+	errTooManyItems = errors.New("too many items")
+
+	// unsupportedMessages and corruptMessages are lowercased parts of exiftool errors
+	unsupportedMessages = []string{"unknown file type", "not supported"}
+	corruptMessages     = []string{"format error", "corrupt", "truncated", "not a valid", "file is empty"}
+)
+
+// errorCategories map errors to an HTTP status and a code, which is also the state of metrics. The first matching one wins,
+// a deadline being checked first as it can be wrapped by the failure of a dependency.
+var errorCategories = []struct {
+	err    error
+	code   string
+	status int
+}{
+	{context.DeadlineExceeded, "timeout", http.StatusGatewayTimeout},
+	{geocode.ErrLimited, "timeout", http.StatusGatewayTimeout},
+	{errInvalidTag, "invalid_tag", http.StatusBadRequest},
+	{errUnmarshal, "unmarshal_error", http.StatusBadRequest},
+	{errNoPublisher, "no_publisher", http.StatusBadRequest},
+	{errTooManyItems, "too_many_items", http.StatusBadRequest},
+	{errInvalidQuery, "invalid_query", http.StatusBadRequest},
+	{errNoAccess, "no_access", http.StatusMethodNotAllowed},
+	{errNotFound, "not_found", http.StatusNotFound},
+	{errNoPreview, "not_found", http.StatusNotFound},
+	{errTooLarge, "too_large", http.StatusRequestEntityTooLarge},
+	{errUnsupported, "unsupported_type", http.StatusUnsupportedMediaType},
+	{errCorrupt, "corrupt_file", http.StatusUnprocessableEntity},
+	{geocode.ErrProvider, "geocode_error", http.StatusBadGateway},
+	{errPublish, "publish_error", http.StatusBadGateway},
+}
+
+func errorCategory(err error) (int, string) {
+	for _, category := range errorCategories {
+		if errors.Is(err, category.err) {
+			return category.status, category.code
+		}
+	}
+
+	return http.StatusInternalServerError, internalErrorCode
+}
+
+// errorMessage returns the message responded for the error: internal ones are hidden, as the details of the geocoding provider
+func errorMessage(err error, status int, code string) string {
+	switch {
+	case code == internalErrorCode:
+		return http.StatusText(status)
+	case errors.Is(err, geocode.ErrProvider):
+		return providerErrorMessage
+	default:
+		return err.Error()
+	}
+}
+
+// httpError responds the error as JSON with its code, the hidden messages being only logged
+func (s Service) httpError(ctx context.Context, w http.ResponseWriter, kind string, err error) {
+	status, code := errorCategory(err)
+
+	s.increaseMetric(ctx, "http", kind, code)
+	httperror.Log(ctx, err, status, kind)
+
+	w.Header().Set("Cache-Control", "no-cache")
+	httpjson.Write(ctx, w, status, model.Error{Code: code, Message: errorMessage(err, status, code)})
+}
+
+// toolError wraps the error message of exiftool with the category of the file, if known
+func toolError(message string) error {
+	lower := strings.ToLower(message)
+
+	for _, part := range unsupportedMessages {
+		if strings.Contains(lower, part) {
+			return fmt.Errorf("%s: %w", message, errUnsupported)
+		}
+	}
+
+	for _, part := range corruptMessages {
+		if strings.Contains(lower, part) {
+			return fmt.Errorf("%s: %w", message, errCorrupt)
+		}
+	}
+
+	return errors.New(message)
+}
+
+func storageError(err error) error {
+	if absto.IsNotExist(err) {
+		return errors.Join(err, errNotFound)
+	}
+
+	return err
+}
@@ -0,0 +1,137 @@
+package exas
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/ViBiOh/exas/pkg/geocode"
+)
+
+func TestErrorCategory(t *testing.T) {
+	t.Parallel()
+
+	type args struct {
+		err error
+	}
+
+	cases := map[string]struct {
+		args       args
+		wantStatus int
+		wantCode   string
+	}{
+		"unknown": {
+			args{
+				err: errors.New("failed"),
+			},
+			http.StatusInternalServerError,
+			"error",
+		},
+		"invalid tag": {
+			args{
+				err: fmt.Errorf("tags `-o`: %w", errInvalidTag),
+			},
+			http.StatusBadRequest,
+			"invalid_tag",
+		},
+		"unsupported": {
+			args{
+				err: fmt.Errorf("run exiftool: %w", toolError("Unknown file type")),
+			},
+			http.StatusUnsupportedMediaType,
+			"unsupported_type",
+		},
+		"corrupt": {
+			args{
+				err: fmt.Errorf("run exiftool: %w", toolError("File format error")),
+			},
+			http.StatusUnprocessableEntity,
+			"corrupt_file",
+		},
+		"geocode": {
+			args{
+				err: fmt.Errorf("search: %w: %w", geocode.ErrProvider, errors.New("HTTP/503")),
+			},
+			http.StatusBadGateway,
+			"geocode_error",
+		},
+		"geocode timeout": {
+			args{
+				err: fmt.Errorf("reverse geocode: %w: %w", geocode.ErrProvider, context.DeadlineExceeded),
+			},
+			http.StatusGatewayTimeout,
+			"timeout",
+		},
+		"timeout": {
+			args{
+				err: errors.Join(errors.New("read output"), context.DeadlineExceeded),
+			},
+			http.StatusGatewayTimeout,
+			"timeout",
+		},
+	}
+
+	for intention, tc := range cases {
+		t.Run(intention, func(t *testing.T) {
+			t.Parallel()
+
+			gotStatus, gotCode := errorCategory(tc.args.err)
+
+			if gotStatus != tc.wantStatus || gotCode != tc.wantCode {
+				t.Errorf("errorCategory() = (%d, `%s`), want (%d, `%s`)", gotStatus, gotCode, tc.wantStatus, tc.wantCode)
+			}
+		})
+	}
+}
+
+func TestErrorMessage(t *testing.T) {
+	t.Parallel()
+
+	type args struct {
+		err error
+	}
+
+	cases := map[string]struct {
+		args args
+		want string
+	}{
+		"client": {
+			args{
+				err: fmt.Errorf("tags `-o`: %w", errInvalidTag),
+			},
+			"tags `-o`: invalid tag",
+		},
+		"internal": {
+			args{
+				err: errors.New("open /tmp/exas-1: no such file"),
+			},
+			"Internal Server Error",
+		},
+		"geocode": {
+			args{
+				err: fmt.Errorf("%w: GET https://api.geocode.earth/v1/reverse?api_key=secret&point.lat=48.8584: HTTP/503", geocode.ErrProvider),
+			},
+			providerErrorMessage,
+		},
+		"geocode timeout": {
+			args{
+				err: fmt.Errorf("%w: GET https://api.geocode.earth/v1/reverse?api_key=secret: %w", geocode.ErrProvider, context.DeadlineExceeded),
+			},
+			providerErrorMessage,
+		},
+	}
+
+	for intention, tc := range cases {
+		t.Run(intention, func(t *testing.T) {
+			t.Parallel()
+
+			status, code := errorCategory(tc.args.err)
+
+			if got := errorMessage(tc.args.err, status, code); got != tc.want {
+				t.Errorf("errorMessage() = `%s`, want `%s`", got, tc.want)
+			}
+		})
+	}
+}
@@ -3,14 +3,17 @@ package exas
 import (
 	"bytes"
 	"context"
+	"crypto/sha256"
 	"encoding/json"
 	"flag"
 	"fmt"
 	"io"
 	"log/slog"
-	"os/exec"
+	"path"
+	"slices"
 	"strings"
 	"sync"
+	"time"
 
 	absto "github.com/ViBiOh/absto/pkg/model"
 	"github.com/ViBiOh/exas/pkg/geocode"
@@ -28,23 +31,70 @@ var bufferPool = sync.Pool{
 	},
 }
 
-type exiftoolErrors []struct {
-	Error string `json:"Error"`
+// Filesystem tags describe our temporary copy, not the submitted file
+var exiftoolArgs = []string{
+	"-json",
+	"-coordFormat", "%+.8f", // signed decimal degrees, instead of `1 deg 2' 3" N`
+	"-api", "QuickTimeUTC", // QuickTime dates are in UTC by specification, they are responded with an offset
+	"--FileName",
+	"--Directory",
+	"--FileModifyDate",
+	"--FileAccessDate",
+	"--FileInodeChangeDate",
+	"--FilePermissions",
+}
+
+// Timed metadata of videos, each sample being in its own document, e.g. `Doc1:GPSLatitude`
+var trackArgs = []string{
+	"-json",
+	"-ee",
+	"-G3",
+	"-coordFormat", "%+.8f",
+	"-GPSLatitude",
+	"-GPSLongitude",
+	"-GPSAltitude",
+	"-GPSDateTime",
 }
 
 type Service struct {
-	storage        absto.Storage
-	tracer         trace.Tracer
-	amqpClient     *amqp.Client
-	metric         metric.Int64Counter
-	amqpExchange   string
-	amqpRoutingKey string
-	geocode        geocode.Service
+	storage          absto.Storage
+	tracer           trace.Tracer
+	amqpClient       *amqp.Client
+	metric           metric.Int64Counter
+	exiftool         *exiftool
+	cache            cache
+	amqpExchange     string
+	amqpRoutingKey   string
+	geocode          geocode.Service
+	geocoder         *geocoder
+	dates            dateParser
+	scanExtensions   []string
+	batchConcurrency int
+	batchMaxSize     int
+	binaryMaxSize    int
+	payloadMaxSize   int64
 }
 
 type Config struct {
-	AmqpExchange   string
-	AmqpRoutingKey string
+	AmqpExchange     string
+	AmqpRoutingKey   string
+	ExiftoolPath     string
+	ExiftoolPool     uint
+	ExiftoolTimeout  time.Duration
+	CacheType        string
+	CacheDirectory   string
+	ScanExtensions   []string
+	ExifDates        []string
+	DatePatterns     []string
+	BatchConcurrency uint
+	BatchMaxSize     uint
+	BinaryMaxSize    uint
+	PayloadMaxSize   uint
+	CacheSize        uint
+
+	GeocodeRoutingKey  string
+	GeocodeConcurrency uint
+	GeocodeRetries     uint
 }
 
 func Flags(fs *flag.FlagSet, prefix string, overrides ...flags.Override) *Config {
@@ -52,24 +102,58 @@ func Flags(fs *flag.FlagSet, prefix string, overrides ...flags.Override) *Config
 
 	flags.New("Exchange", "AMQP Exchange Name").Prefix(prefix).DocPrefix("exas").StringVar(fs, &config.AmqpExchange, "fibr", overrides)
 	flags.New("RoutingKey", "AMQP Routing Key to fibr").Prefix(prefix).DocPrefix("exas").StringVar(fs, &config.AmqpRoutingKey, "exif_output", overrides)
+	flags.New("ExiftoolPath", "Path to exiftool binary").Prefix(prefix).DocPrefix("exas").StringVar(fs, &config.ExiftoolPath, "./exiftool", overrides)
+	flags.New("ExiftoolPool", "Number of long-lived exiftool processes").Prefix(prefix).DocPrefix("exas").UintVar(fs, &config.ExiftoolPool, 4, overrides)
+	flags.New("ExiftoolTimeout", "Timeout of a single exiftool call, process is restarted when reached").Prefix(prefix).DocPrefix("exas").DurationVar(fs, &config.ExiftoolTimeout, 30*time.Second, overrides)
+	flags.New("BatchConcurrency", "Number of files extracted concurrently in a batch or a scan, 0 for no limit").Prefix(prefix).DocPrefix("exas").UintVar(fs, &config.BatchConcurrency, 4, overrides)
+	flags.New("BatchMaxSize", "Max number of pathnames in a batch, 0 for no limit").Prefix(prefix).DocPrefix("exas").UintVar(fs, &config.BatchMaxSize, 1000, overrides)
+	flags.New("BinaryMaxSize", "Max size in bytes of a binary tag responded in base64, larger ones are dropped").Prefix(prefix).DocPrefix("exas").UintVar(fs, &config.BinaryMaxSize, 64*1024, overrides)
+	flags.New("PayloadMaxSize", "Max size in bytes of an uploaded file, 0 for unlimited").Prefix(prefix).DocPrefix("exas").UintVar(fs, &config.PayloadMaxSize, 0, overrides)
+	flags.New("CacheType", "Cache of extracted metadata, keyed by file size and date or by content hash: memory, storage or empty to disable").Prefix(prefix).DocPrefix("exas").StringVar(fs, &config.CacheType, "", overrides)
+	flags.New("CacheSize", "Number of items kept in memory cache, 0 to disable").Prefix(prefix).DocPrefix("exas").UintVar(fs, &config.CacheSize, 10000, overrides)
+	flags.New("CacheDirectory", "Directory of JSON sidecars for storage cache").Prefix(prefix).DocPrefix("exas").StringVar(fs, &config.CacheDirectory, "/.exas/", overrides)
+	flags.New("GeocodeRoutingKey", "AMQP Routing Key of geocode messages, geocoding of storage files is done asynchronously when set").Prefix(prefix).DocPrefix("exas").StringVar(fs, &config.GeocodeRoutingKey, "", overrides)
+	flags.New("GeocodeConcurrency", "Number of files geocoded concurrently when asynchronous").Prefix(prefix).DocPrefix("exas").UintVar(fs, &config.GeocodeConcurrency, 2, overrides)
+	flags.New("GeocodeRetries", "Number of retries of an asynchronous geocoding failing on network, server or too many requests errors").Prefix(prefix).DocPrefix("exas").UintVar(fs, &config.GeocodeRetries, 3, overrides)
+	flags.New("ExifDates", "Tags read for the creation date, by priority").Prefix(prefix).DocPrefix("exas").StringSliceVar(fs, &config.ExifDates, defaultExifDates, overrides)
+	flags.New("DatePatterns", "Go layouts of dates without offset, by priority").Prefix(prefix).DocPrefix("exas").StringSliceVar(fs, &config.DatePatterns, defaultDatePatterns, overrides)
+	flags.New("ScanExtensions", "File extensions extracted when scanning a directory, empty for all").Prefix(prefix).DocPrefix("exas").StringSliceVar(fs, &config.ScanExtensions, []string{".jpg", ".jpeg", ".png", ".heic", ".heif", ".tif", ".tiff", ".dng", ".cr2", ".cr3", ".nef", ".arw", ".orf", ".rw2", ".mp4", ".mov"}, overrides)
 
 	return &config
 }
 
-func New(config *Config, geocodeService geocode.Service, amqpClient *amqp.Client, storageService absto.Storage, meterProvider metric.MeterProvider, tracerProvider trace.TracerProvider) Service {
+func New(config *Config, geocodeService geocode.Service, amqpClient *amqp.Client, storageService absto.Storage, meterProvider metric.MeterProvider, tracerProvider trace.TracerProvider) (Service, error) {
+	cache, err := newCache(config, storageService)
+	if err != nil {
+		return Service{}, fmt.Errorf("cache: %w", err)
+	}
+
 	service := Service{
-		geocode:        geocodeService,
-		storage:        storageService,
-		amqpClient:     amqpClient,
-		amqpExchange:   config.AmqpExchange,
-		amqpRoutingKey: config.AmqpRoutingKey,
+		cache:            cache,
+		geocode:          geocodeService,
+		storage:          storageService,
+		amqpClient:       amqpClient,
+		exiftool:         newExiftool(config.ExiftoolPath, config.ExiftoolPool, config.ExiftoolTimeout),
+		amqpExchange:     config.AmqpExchange,
+		amqpRoutingKey:   config.AmqpRoutingKey,
+		scanExtensions:   normalizeExtensions(config.ScanExtensions),
+		batchConcurrency: int(config.BatchConcurrency),
+		batchMaxSize:     int(config.BatchMaxSize),
+		binaryMaxSize:    int(config.BinaryMaxSize),
+		payloadMaxSize:   int64(config.PayloadMaxSize),
+		dates: dateParser{
+			tags:     config.ExifDates,
+			patterns: config.DatePatterns,
+		},
+	}
+
+	if len(config.GeocodeRoutingKey) != 0 && amqpClient != nil && geocodeService.Enabled() {
+		service.geocoder = newGeocoder(config)
 	}
 
 	if meterProvider != nil {
 		meter := meterProvider.Meter("github.com/ViBiOh/exas/pkg/exas")
 
-		var err error
-
 		service.metric, err = meter.Int64Counter("exas.item")
 		if err != nil {
 			slog.LogAttrs(context.Background(), slog.LevelError, "create counter", slog.Any("error", err))
@@ -80,70 +164,207 @@ func New(config *Config, geocodeService geocode.Service, amqpClient *amqp.Client
 		service.tracer = tracerProvider.Tracer("exas")
 	}
 
-	return service
+	return service, nil
+}
+
+// Start runs the asynchronous geocoding workers, until the Service is closed.
+func (s Service) Start(ctx context.Context) {
+	if s.geocoder == nil {
+		return
+	}
+
+	s.startGeocoder(ctx)
+}
+
+func (s Service) Close() {
+	if s.geocoder != nil {
+		s.geocoder.close()
+	}
+
+	s.exiftool.Close()
+}
+
+func (s Service) get(ctx context.Context, input io.Reader, extension string, opts options) (model.Exif, error) {
+	name, err := writeTemp(input, extension)
+	if err != nil {
+		return model.Exif{}, fmt.Errorf("write input: %w", err)
+	}
+	defer removeWithLog(ctx, name)
+
+	return s.extract(ctx, name, opts)
+}
+
+func (s Service) getContent(ctx context.Context, input io.Reader, opts options) (model.Exif, error) {
+	if s.cache == nil {
+		exif, err := s.get(ctx, input, "", opts)
+		if err != nil {
+			return exif, err
+		}
+
+		return s.resolveGeocode(ctx, absto.Item{}, "", exif, opts), nil
+	}
+
+	hasher := sha256.New()
+
+	name, err := writeTemp(io.TeeReader(input, hasher), "")
+	if err != nil {
+		return model.Exif{}, fmt.Errorf("write input: %w", err)
+	}
+	defer removeWithLog(ctx, name)
+
+	key := opts.cacheKey(contentCacheKey(hasher.Sum(nil)))
+
+	if exif, ok := s.getCache(ctx, key, opts.refresh); ok {
+		return exif, nil
+	}
+
+	exif, err := s.extract(ctx, name, opts)
+	if err != nil {
+		return exif, err
+	}
+
+	return s.resolveGeocode(ctx, absto.Item{}, key, exif, opts), nil
+}
+
+func (s Service) getItem(ctx context.Context, item absto.Item, opts options) (model.Exif, error) {
+	key := opts.cacheKey(itemCacheKey(item))
+
+	if exif, ok := s.getCache(ctx, key, opts.refresh); ok {
+		return exif, nil
+	}
+
+	reader, err := s.storage.ReadFrom(ctx, item.Pathname)
+	if err != nil {
+		return model.Exif{}, fmt.Errorf("read from storage: %w", storageError(err))
+	}
+	defer closeWithLog(ctx, reader, "getItem", item.Pathname)
+
+	exif, err := s.get(ctx, reader, path.Ext(item.Pathname), opts)
+	if err != nil {
+		return exif, err
+	}
+
+	return s.resolveGeocode(ctx, item, key, exif, opts), nil
 }
 
-func (s Service) get(ctx context.Context, input io.Reader) (exif model.Exif, err error) {
+func (s Service) extract(ctx context.Context, name string, opts options) (exif model.Exif, err error) {
 	ctx, end := telemetry.StartSpan(ctx, s.tracer, "exiftool")
 	defer end(&err)
 
-	cmd := exec.Command("./exiftool", "-json", "-")
+	exifData, err := s.readTags(ctx, name)
+	if err != nil {
+		return exif, err
+	}
 
-	buffer := bufferPool.Get().(*bytes.Buffer)
-	defer bufferPool.Put(buffer)
+	binaryTags := s.binaryTags(ctx, exifData, opts.binary)
+	removeBinary(exifData)
 
-	buffer.Reset()
-	cmd.Stdin = input
-	cmd.Stdout = buffer
-	cmd.Stderr = buffer
+	exif.Data = exifData
+	exif.Metadata = getMetadata(exifData)
+
+	exif, offset := s.dates.parse(exif)
+
+	if isVideo(exifData) {
+		if exif.Metadata.Video.Track, err = s.readTrack(ctx, name); err != nil {
+			slog.LogAttrs(ctx, slog.LevelWarn, "extract track", slog.String("name", name), slog.Any("error", err))
+		}
 
-	if err := handleExifToolErr(cmd.Run(), buffer); err != nil {
+		// exiftool responds them in the local time of the process, which is UTC in the container
+		if slices.Contains(quickTimeDates, exif.DateSource) {
+			offset = offsetUTC
+		}
+	}
+
+	if exif.Geocode, err = geocode.ExtractLocation(exifData); err != nil {
+		slog.LogAttrs(ctx, slog.LevelWarn, "extract location", slog.String("name", name), slog.Any("error", err))
+	}
+
+	if !exif.Geocode.HasCoordinates() {
+		if inferred, ok := s.geocode.Interpolate(ctx, exif.Date, offset != offsetUnknown); ok {
+			exif.Geocode = inferred
+		}
+	}
+
+	exif = s.localizeDate(ctx, exif, offset)
+
+	if exif.Data, err = s.readData(ctx, name, exifData, binaryTags, opts); err != nil {
 		return exif, err
 	}
 
-	var exifs []map[string]any
-	if err := json.NewDecoder(buffer).Decode(&exifs); err != nil {
-		return exif, fmt.Errorf("decode exiftool output: %w", err)
+	return exif, nil
+}
+
+// readData returns the data responded, the one used for normalization unless the request has its own exiftool arguments
+func (s Service) readData(ctx context.Context, name string, data map[string]any, binaryTags []string, opts options) (map[string]any, error) {
+	if opts.hasDataArgs() {
+		var err error
+
+		if data, err = s.runTags(ctx, append(append(slices.Clone(exiftoolArgs), opts.dataArgs()...), name)...); err != nil {
+			return nil, fmt.Errorf("read requested tags: %w", err)
+		}
+
+		removeBinary(data)
 	}
 
-	var exifData map[string]any
-	if len(exifs) > 0 {
-		exifData = exifs[0]
+	if len(binaryTags) != 0 {
+		if err := s.readBinary(ctx, name, binaryTags, data, opts.groups); err != nil {
+			slog.LogAttrs(ctx, slog.LevelWarn, "extract binary", slog.String("name", name), slog.Any("error", err))
+		}
 	}
 
-	for key, value := range exifData {
+	return data, nil
+}
+
+func removeBinary(data map[string]any) {
+	for key, value := range data {
 		if strValue, ok := value.(string); ok && strings.HasPrefix(strValue, "(Binary data") {
-			delete(exifData, key)
+			delete(data, key)
 		}
 	}
+}
 
-	exif.Data = exifData
-	exif.Date = getDate(exif)
+func (s Service) readTags(ctx context.Context, name string) (map[string]any, error) {
+	return s.runTags(ctx, append(exiftoolArgs, name)...)
+}
 
-	exif.Geocode, err = s.geocode.GetGeocoding(ctx, exif)
+func (s Service) readTrack(ctx context.Context, name string) (model.Track, error) {
+	data, err := s.runTags(ctx, append(trackArgs, name)...)
 	if err != nil {
-		return exif, fmt.Errorf("append geocoding: %w", err)
+		return model.Track{}, err
 	}
 
-	return exif, nil
+	return geocode.ExtractTrack(data)
 }
 
-func handleExifToolErr(err error, buffer *bytes.Buffer) error {
-	if err == nil {
-		return nil
+func (s Service) runTags(ctx context.Context, args ...string) (map[string]any, error) {
+	buffer := bufferPool.Get().(*bytes.Buffer)
+	defer bufferPool.Put(buffer)
+
+	buffer.Reset()
+
+	if err := s.exiftool.run(ctx, buffer, args...); err != nil {
+		return nil, fmt.Errorf("run exiftool: %w", err)
 	}
 
-	var toolErrs exiftoolErrors
-	stderr := buffer.Bytes()
+	return decodeExiftool(buffer)
+}
+
+func decodeExiftool(buffer *bytes.Buffer) (map[string]any, error) {
+	var exifs []map[string]any
+	if err := json.NewDecoder(buffer).Decode(&exifs); err != nil {
+		return nil, fmt.Errorf("decode exiftool output: %w", err)
+	}
 
-	_ = json.Unmarshal(stderr, &toolErrs)
+	if len(exifs) == 0 {
+		return nil, nil
+	}
 
-	if len(toolErrs) > 0 && toolErrs[0].Error == "Unknown file type" {
-		buffer.Reset()
-		buffer.WriteString("[{}]")
+	exifData := exifs[0]
+	delete(exifData, "SourceFile")
 
-		return nil
+	if toolErr, ok := exifData["Error"].(string); ok {
+		return nil, fmt.Errorf("extract exif: %w", toolError(toolErr))
 	}
 
-	return fmt.Errorf("extract exif `%s`: %w", stderr, err)
+	return exifData, nil
 }
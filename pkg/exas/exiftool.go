@@ -0,0 +1,292 @@
+package exas
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+var (
+	errExiftoolClosed = errors.New("exiftool pool is closed")
+	errExiftoolDied   = errors.New("exiftool process died")
+
+	// exiftoolOptions are the lowercased options of exiftool, without their numeric suffix, long and short forms
+	exiftoolOptions = map[string]struct{}{
+		"@": {}, "a": {}, "api": {}, "argformat": {}, "args": {}, "b": {}, "binary": {}, "c": {}, "charset": {},
+		"common_args": {}, "composite": {}, "config": {}, "coordformat": {}, "csv": {}, "csvdelim": {}, "d": {},
+		"dateformat": {}, "decimal": {}, "delete_original": {}, "diff": {}, "duplicates": {}, "e": {}, "ec": {},
+		"echo": {}, "ee": {}, "efile": {}, "escapec": {}, "escapehtml": {}, "escapexml": {}, "ex": {}, "execute": {},
+		"ext": {}, "extension": {}, "extractembedded": {}, "f": {}, "fast": {}, "file": {}, "fileorder": {},
+		"fixbase": {}, "forceprint": {}, "g": {}, "geotag": {}, "globaltimeshift": {}, "groupheadings": {},
+		"groupnames": {}, "h": {}, "hex": {}, "htmldump": {}, "htmlformat": {}, "i": {}, "if": {}, "ignore": {},
+		"ignoreminorerrors": {}, "j": {}, "json": {}, "k": {}, "l": {}, "lang": {}, "latin": {}, "list": {},
+		"list_dir": {}, "listd": {}, "listf": {}, "listg": {}, "listgeo": {}, "listitem": {}, "listr": {},
+		"listw": {}, "listwf": {}, "listx": {}, "long": {}, "m": {}, "n": {}, "o": {}, "out": {},
+		"overwrite_original": {}, "overwrite_original_in_place": {}, "p": {}, "password": {}, "pause": {},
+		"php": {}, "plot": {}, "preserve": {}, "printconv": {}, "printformat": {}, "progress": {}, "q": {},
+		"quiet": {}, "r": {}, "recurse": {}, "restore_original": {}, "s": {}, "scanforxmp": {}, "sep": {},
+		"separator": {}, "short": {}, "sort": {}, "srcfile": {}, "stay_open": {}, "struct": {}, "t": {}, "tab": {},
+		"table": {}, "tagout": {}, "tagsfromfile": {}, "textout": {}, "u": {}, "unknown": {}, "use": {},
+		"userparam": {}, "v": {}, "ver": {}, "verbose": {}, "veryshort": {}, "w": {}, "wext": {}, "wm": {},
+		"writemode": {}, "x": {}, "xmlformat": {}, "z": {}, "zip": {},
+	}
+)
+
+// isExiftoolOption reports if an argument built from the tag would be read as an option, e.g. `-execute` ending the command of the stay_open protocol or `-o` writing another file
+func isExiftoolOption(tag string) bool {
+	_, ok := exiftoolOptions[strings.TrimRight(strings.ToLower(tag), "0123456789")]
+
+	return ok
+}
+
+// exiftool is a pool of long-lived exiftool processes, driven with `-stay_open True -@ -`.
+// Each command is written on stdin, one argument per line, and framed with `-executeN`.
+type exiftool struct {
+	processes chan *exiftoolProcess
+	done      chan struct{}
+	path      string
+	timeout   time.Duration
+}
+
+func newExiftool(path string, size uint, timeout time.Duration) *exiftool {
+	if size == 0 {
+		size = 1
+	}
+
+	pool := &exiftool{
+		path:      path,
+		timeout:   timeout,
+		processes: make(chan *exiftoolProcess, size),
+		done:      make(chan struct{}),
+	}
+
+	for range size {
+		pool.processes <- &exiftoolProcess{path: path}
+	}
+
+	return pool
+}
+
+func (e *exiftool) run(ctx context.Context, stdout *bytes.Buffer, args ...string) error {
+	var process *exiftoolProcess
+
+	select {
+	case <-e.done:
+		return errExiftoolClosed
+	case <-ctx.Done():
+		return ctx.Err()
+	case process = <-e.processes:
+	}
+
+	defer func() { e.processes <- process }()
+
+	if e.timeout > 0 {
+		var cancel context.CancelFunc
+
+		ctx, cancel = context.WithTimeout(ctx, e.timeout)
+		defer cancel()
+	}
+
+	err := process.execute(ctx, stdout, args)
+	if errors.Is(err, errExiftoolDied) && ctx.Err() == nil {
+		stdout.Reset()
+		err = process.execute(ctx, stdout, args)
+	}
+
+	return err
+}
+
+func (e *exiftool) Close() {
+	close(e.done)
+
+	for range cap(e.processes) {
+		(<-e.processes).close()
+	}
+}
+
+type exiftoolProcess struct {
+	cmd      *exec.Cmd
+	stdin    io.WriteCloser
+	stdout   *bufio.Reader
+	stderr   *bufio.Reader
+	path     string
+	sequence uint64
+}
+
+func (p *exiftoolProcess) start() error {
+	cmd := exec.Command(p.path, "-stay_open", "True", "-@", "-")
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return fmt.Errorf("stdin: %w", err)
+	}
+
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return fmt.Errorf("stdout: %w", err)
+	}
+
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return fmt.Errorf("stderr: %w", err)
+	}
+
+	if err = cmd.Start(); err != nil {
+		return fmt.Errorf("start: %w", err)
+	}
+
+	p.cmd = cmd
+	p.stdin = stdin
+	p.stdout = bufio.NewReaderSize(stdout, 32*1024)
+	p.stderr = bufio.NewReader(stderr)
+
+	return nil
+}
+
+// execute runs the command on the process, started if needed. The process is killed on failure of the exchange, then restarted on next call.
+func (p *exiftoolProcess) execute(ctx context.Context, stdout *bytes.Buffer, args []string) error {
+	if p.cmd == nil {
+		if err := p.start(); err != nil {
+			return fmt.Errorf("start exiftool: %w", err)
+		}
+	}
+
+	var stderr bytes.Buffer
+
+	if err := p.exchange(ctx, stdout, &stderr, args); err != nil {
+		slog.LogAttrs(ctx, slog.LevelWarn, "exiftool process killed, it will be restarted on next call", slog.Any("error", err))
+		p.kill()
+
+		return err
+	}
+
+	return exiftoolStderr(stderr.String())
+}
+
+func (p *exiftoolProcess) exchange(ctx context.Context, stdout, stderr *bytes.Buffer, args []string) (err error) {
+	p.sequence++
+	sentinel := fmt.Appendf(nil, "{ready%d}\n", p.sequence)
+
+	var command bytes.Buffer
+	for _, arg := range args {
+		command.WriteString(arg)
+		command.WriteByte('\n')
+	}
+
+	fmt.Fprintf(&command, "-echo4\n{ready%d}\n-execute%d\n", p.sequence, p.sequence)
+
+	if _, err = p.stdin.Write(command.Bytes()); err != nil {
+		return fmt.Errorf("write command: %w: %w", errExiftoolDied, err)
+	}
+
+	outputs := make(chan error, 2)
+	go func() { outputs <- readUntil(p.stdout, stdout, sentinel) }()
+	go func() { outputs <- readUntil(p.stderr, stderr, sentinel) }()
+
+	done := ctx.Done()
+
+	for remaining := 2; remaining > 0; {
+		select {
+		case <-done:
+			err = errors.Join(err, ctx.Err())
+			done = nil
+
+			p.kill()
+		case outputErr := <-outputs:
+			if outputErr != nil {
+				err = errors.Join(err, fmt.Errorf("read output: %w: %w", errExiftoolDied, outputErr))
+			}
+
+			remaining--
+		}
+	}
+
+	return err
+}
+
+func (p *exiftoolProcess) kill() {
+	if p.cmd == nil {
+		return
+	}
+
+	if p.cmd.ProcessState == nil {
+		_ = p.cmd.Process.Kill()
+		_ = p.cmd.Wait()
+	}
+
+	p.cmd = nil
+}
+
+func (p *exiftoolProcess) close() {
+	if p.cmd == nil {
+		return
+	}
+
+	if _, err := io.WriteString(p.stdin, "-stay_open\nFalse\n"); err != nil {
+		p.kill()
+		return
+	}
+
+	if err := p.stdin.Close(); err != nil {
+		p.kill()
+		return
+	}
+
+	if err := p.cmd.Wait(); err != nil {
+		slog.LogAttrs(context.Background(), slog.LevelWarn, "wait exiftool", slog.Any("error", err))
+	}
+
+	p.cmd = nil
+}
+
+func readUntil(reader *bufio.Reader, output *bytes.Buffer, sentinel []byte) error {
+	for {
+		chunk, err := reader.ReadSlice('\n')
+		output.Write(chunk)
+
+		if err != nil && !errors.Is(err, bufio.ErrBufferFull) {
+			return err
+		}
+
+		if bytes.HasSuffix(output.Bytes(), sentinel) {
+			output.Truncate(output.Len() - len(sentinel))
+			return nil
+		}
+	}
+}
+
+// exiftoolStderr reports errors, and warnings of tags that can't be written, as exiftool only warns on them and writes the other tags
+func exiftoolStderr(stderr string) error {
+	var errs, invalids []string
+
+	for line := range strings.Lines(stderr) {
+		switch {
+		case strings.HasPrefix(line, "Error"):
+			errs = append(errs, strings.TrimSpace(line))
+		case isInvalidTagWarning(line):
+			invalids = append(invalids, strings.TrimSpace(line))
+		}
+	}
+
+	if len(errs) != 0 {
+		return fmt.Errorf("exiftool: %w", toolError(strings.Join(errs, ", ")))
+	}
+
+	if len(invalids) != 0 {
+		return fmt.Errorf("exiftool: %s: %w", strings.Join(invalids, ", "), errInvalidTag)
+	}
+
+	return nil
+}
+
+// isInvalidTagWarning matches e.g. `Warning: Tag 'Foo' is not defined` or `Warning: Sorry, Foo is not writable`
+func isInvalidTagWarning(line string) bool {
+	return (strings.HasPrefix(line, "Warning: Tag '") && strings.Contains(line, "not defined")) || strings.HasPrefix(line, "Warning: Sorry, ")
+}
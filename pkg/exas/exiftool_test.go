@@ -0,0 +1,277 @@
+package exas
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"image"
+	"image/jpeg"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/iotest"
+	"time"
+)
+
+// fakeExiftool mimics the stay_open protocol: it answers the arguments of each command on stdout, and behaves on the `sleep`, `exit` and `fail` arguments.
+// With a `.preview` file beside the script, files have a PreviewImage, extracted as `preview`.
+const fakeExiftool = `#!/bin/sh
+args=""
+while IFS= read -r line; do
+	case "$line" in
+	-stay_open|True|False|-@|-|-echo4|"{ready"*) ;;
+	-execute*)
+		n="${line#-execute}"
+		case "$args" in *sleep*) sleep 5 ;; esac
+		case "$args" in *fail*) echo "Error: File format error - fail" >&2 ;; esac
+		if [ ! -f "$0.preview" ]; then
+			printf '[{"Args":"%s"}]\n{ready%s}\n' "$args" "$n"
+		elif [ "${args#-b-PreviewImage}" != "$args" ]; then
+			printf 'preview{ready%s}\n' "$n"
+		else
+			printf '[{"Args":"%s","PreviewImage":"(Binary data 7 bytes, use -b option to extract)"}]\n{ready%s}\n' "$args" "$n"
+		fi
+		printf '{ready%s}\n' "$n" >&2
+		case "$args" in *exit*) exit 0 ;; esac
+		args=""
+		;;
+	*) args="$args$line" ;;
+	esac
+done
+`
+
+func newFakeExiftool(t *testing.T, timeout time.Duration) *exiftool {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "exiftool")
+	if err := os.WriteFile(path, []byte(fakeExiftool), 0o700); err != nil {
+		t.Fatal(err)
+	}
+
+	pool := newExiftool(path, 1, timeout)
+	t.Cleanup(pool.Close)
+
+	return pool
+}
+
+// newLocalExiftool starts the exiftool of the PATH, skipping the test when it's not installed.
+func newLocalExiftool(t *testing.T) *exiftool {
+	t.Helper()
+
+	path, err := exec.LookPath("exiftool")
+	if err != nil {
+		t.Skip("exiftool is not in PATH")
+	}
+
+	pool := newExiftool(path, 1, time.Minute)
+	t.Cleanup(pool.Close)
+
+	return pool
+}
+
+// writeJPEG writes a small JPEG image at the given name.
+func writeJPEG(t *testing.T, name string) {
+	t.Helper()
+
+	file, err := os.Create(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	if err = jpeg.Encode(file, image.NewGray(image.Rect(0, 0, 8, 8)), nil); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestExiftoolRun(t *testing.T) {
+	t.Parallel()
+
+	type call struct {
+		arg     string
+		want    string
+		wantErr error
+	}
+
+	cases := map[string]struct {
+		calls []call
+	}{
+		"reuse": {
+			[]call{
+				{"first", `[{"Args":"first"}]`, nil},
+				{"second", `[{"Args":"second"}]`, nil},
+			},
+		},
+		"tool error keeps process": {
+			[]call{
+				{"fail", `[{"Args":"fail"}]`, errCorrupt},
+				{"second", `[{"Args":"second"}]`, nil},
+			},
+		},
+		"retry on dead process": {
+			[]call{
+				{"exit", `[{"Args":"exit"}]`, nil},
+				{"second", `[{"Args":"second"}]`, nil},
+			},
+		},
+		"restart after timeout": {
+			[]call{
+				{"sleep", "", context.DeadlineExceeded},
+				{"second", `[{"Args":"second"}]`, nil},
+			},
+		},
+	}
+
+	for intention, tc := range cases {
+		t.Run(intention, func(t *testing.T) {
+			t.Parallel()
+
+			pool := newFakeExiftool(t, time.Second)
+
+			for _, call := range tc.calls {
+				var output bytes.Buffer
+
+				gotErr := pool.run(context.Background(), &output, call.arg)
+
+				if !errors.Is(gotErr, call.wantErr) {
+					t.Errorf("run(`%s`) error = %v, want %v", call.arg, gotErr, call.wantErr)
+				} else if got := strings.TrimSpace(output.String()); call.wantErr == nil && got != call.want {
+					t.Errorf("run(`%s`) = `%s`, want `%s`", call.arg, got, call.want)
+				}
+			}
+		})
+	}
+}
+
+func TestReadUntil(t *testing.T) {
+	t.Parallel()
+
+	type args struct {
+		input string
+	}
+
+	cases := map[string]struct {
+		args    args
+		want    string
+		wantErr error
+	}{
+		"simple": {
+			args{
+				input: "[{}]\n{ready1}\n",
+			},
+			"[{}]\n",
+			nil,
+		},
+		"sentinel split across reads": {
+			args{
+				input: "a line longer than the buffer{ready1}\n",
+			},
+			"a line longer than the buffer",
+			nil,
+		},
+		"remaining output": {
+			args{
+				input: "[{}]\n{ready1}\n[{}]\n{ready2}\n",
+			},
+			"[{}]\n",
+			nil,
+		},
+		"eof before sentinel": {
+			args{
+				input: "[{}]\n{ready",
+			},
+			"[{}]\n{ready",
+			io.EOF,
+		},
+	}
+
+	for intention, tc := range cases {
+		t.Run(intention, func(t *testing.T) {
+			t.Parallel()
+
+			var output bytes.Buffer
+
+			reader := bufio.NewReaderSize(iotest.OneByteReader(strings.NewReader(tc.args.input)), 16)
+			gotErr := readUntil(reader, &output, []byte("{ready1}\n"))
+
+			if !errors.Is(gotErr, tc.wantErr) {
+				t.Errorf("readUntil() error = %v, want %v", gotErr, tc.wantErr)
+			} else if output.String() != tc.want {
+				t.Errorf("readUntil() = `%s`, want `%s`", output.String(), tc.want)
+			}
+		})
+	}
+}
+
+func TestExiftoolStderr(t *testing.T) {
+	t.Parallel()
+
+	type args struct {
+		stderr string
+	}
+
+	cases := map[string]struct {
+		args    args
+		want    string
+		wantErr error
+	}{
+		"empty": {
+			args{},
+			"",
+			nil,
+		},
+		"warning": {
+			args{
+				stderr: "Warning: [minor] Bad MakerNotes offset\n",
+			},
+			"",
+			nil,
+		},
+		"unknown tag": {
+			args{
+				stderr: "Warning: Tag 'Foo' is not defined\nNothing to do.\n",
+			},
+			"exiftool: Warning: Tag 'Foo' is not defined: invalid tag",
+			errInvalidTag,
+		},
+		"not writable": {
+			args{
+				stderr: "Warning: Sorry, ImageWidth is not writable\n",
+			},
+			"exiftool: Warning: Sorry, ImageWidth is not writable: invalid tag",
+			errInvalidTag,
+		},
+		"unsupported": {
+			args{
+				stderr: "Warning: minor\nError: Unknown file type - /tmp/exas-1\n",
+			},
+			"exiftool: Error: Unknown file type - /tmp/exas-1: unsupported file type",
+			errUnsupported,
+		},
+		"corrupt": {
+			args{
+				stderr: "Error: Not a valid JPEG - /tmp/exas-1\nError: Truncated\n",
+			},
+			"exiftool: Error: Not a valid JPEG - /tmp/exas-1, Error: Truncated: corrupt file",
+			errCorrupt,
+		},
+	}
+
+	for intention, tc := range cases {
+		t.Run(intention, func(t *testing.T) {
+			t.Parallel()
+
+			gotErr := exiftoolStderr(tc.args.stderr)
+
+			if !errors.Is(gotErr, tc.wantErr) {
+				t.Errorf("exiftoolStderr() error = %v, want %v", gotErr, tc.wantErr)
+			} else if gotErr != nil && gotErr.Error() != tc.want {
+				t.Errorf("exiftoolStderr() = `%s`, want `%s`", gotErr, tc.want)
+			}
+		})
+	}
+}
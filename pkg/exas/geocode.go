@@ -0,0 +1,245 @@
+package exas
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	absto "github.com/ViBiOh/absto/pkg/model"
+	"github.com/ViBiOh/exas/pkg/geocode"
+	"github.com/ViBiOh/exas/pkg/model"
+)
+
+const (
+	geocodeQueueSize  = 1000
+	geocodeRetryDelay = time.Second
+)
+
+type amqpGeocodeResponse struct {
+	Item    absto.Item    `json:"item"`
+	Geocode model.Geocode `json:"geocode"`
+}
+
+type geocodeJob struct {
+	exif     model.Exif
+	item     absto.Item
+	key      string
+	language string
+}
+
+// geocoder resolves addresses of storage files in background, then publishes them in a dedicated message
+type geocoder struct {
+	jobs        chan geocodeJob
+	stop        chan struct{}
+	done        chan struct{}
+	routingKey  string
+	concurrency int
+	retries     int
+	retryDelay  time.Duration
+	mutex       sync.Mutex
+	started     bool
+	stopped     bool
+}
+
+func newGeocoder(config *Config) *geocoder {
+	return &geocoder{
+		jobs:        make(chan geocodeJob, geocodeQueueSize),
+		stop:        make(chan struct{}),
+		done:        make(chan struct{}),
+		routingKey:  config.GeocodeRoutingKey,
+		concurrency: max(1, int(config.GeocodeConcurrency)),
+		retries:     int(config.GeocodeRetries),
+		retryDelay:  geocodeRetryDelay,
+	}
+}
+
+// begin marks the workers as running, unless the geocoder is already closed
+func (g *geocoder) begin() bool {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	if g.stopped {
+		return false
+	}
+
+	g.started = true
+
+	return true
+}
+
+// close stops accepting jobs and waits for the queued ones to be processed, if workers are running
+func (g *geocoder) close() {
+	g.mutex.Lock()
+
+	if g.stopped {
+		g.mutex.Unlock()
+		return
+	}
+
+	g.stopped = true
+	started := g.started
+	close(g.stop)
+
+	g.mutex.Unlock()
+
+	if started {
+		<-g.done
+	}
+}
+
+// enqueue never blocks once the geocoder is closed, the job being dropped
+func (g *geocoder) enqueue(ctx context.Context, job geocodeJob) bool {
+	select {
+	case <-g.stop:
+		return false
+	default:
+	}
+
+	select {
+	case <-g.stop:
+		return false
+	case <-ctx.Done():
+		return false
+	case g.jobs <- job:
+		return true
+	}
+}
+
+// resolveGeocode fills the address of the Exif, coarsens its coordinates, then caches it under the given key, if any.
+// Storage items are enqueued instead when geocoding is asynchronous, the cache being set once resolved. A failure only loses the address,
+// the Exif being then left out of cache for the address to be resolved on next request.
+func (s Service) resolveGeocode(ctx context.Context, item absto.Item, key string, exif model.Exif, opts options) model.Exif {
+	if !s.geocode.Enabled() || !exif.Geocode.HasCoordinates() {
+		exif = s.geocode.CoarsenExif(exif)
+		s.setCache(ctx, key, exif)
+
+		return exif
+	}
+
+	if s.geocoder != nil && len(item.Pathname) != 0 {
+		if !s.geocoder.enqueue(ctx, geocodeJob{item: item, key: key, exif: exif, language: opts.language}) {
+			s.increaseMetric(ctx, "geocode", "address", "dropped")
+		}
+
+		exif = s.geocode.CoarsenExif(exif)
+
+		return exif
+	}
+
+	resolved, err := s.geocode.Resolve(ctx, exif.Geocode, opts.language)
+	if err != nil {
+		if errors.Is(err, geocode.ErrLimited) {
+			slog.LogAttrs(ctx, slog.LevelWarn, "geocoding skipped", slog.String("item", item.Pathname), slog.Any("error", err))
+		} else {
+			s.increaseMetric(ctx, "geocode", "address", "error")
+			slog.LogAttrs(ctx, slog.LevelError, "resolve geocode", slog.String("item", item.Pathname), slog.Any("error", err))
+		}
+
+		exif = s.geocode.CoarsenExif(exif)
+
+		return exif
+	}
+
+	exif.Geocode = resolved
+	exif = s.geocode.CoarsenExif(exif)
+	s.setCache(ctx, key, exif)
+
+	return exif
+}
+
+func (s Service) startGeocoder(ctx context.Context) {
+	if !s.geocoder.begin() {
+		return
+	}
+
+	defer close(s.geocoder.done)
+
+	var wg sync.WaitGroup
+
+	for range s.geocoder.concurrency {
+		wg.Go(func() {
+			for {
+				select {
+				case job := <-s.geocoder.jobs:
+					s.runGeocodeJob(ctx, job)
+				case <-s.geocoder.stop:
+					for {
+						select {
+						case job := <-s.geocoder.jobs:
+							s.runGeocodeJob(ctx, job)
+						default:
+							return
+						}
+					}
+				}
+			}
+		})
+	}
+
+	wg.Wait()
+}
+
+func (s Service) runGeocodeJob(ctx context.Context, job geocodeJob) {
+	if err := s.geocodeJob(ctx, job); err != nil {
+		_, code := errorCategory(err)
+		s.increaseMetric(ctx, "geocode", "address", code)
+		slog.LogAttrs(ctx, slog.LevelError, "async geocode", slog.String("item", job.item.Pathname), slog.Any("error", err))
+	}
+}
+
+func (s Service) geocodeJob(ctx context.Context, job geocodeJob) error {
+	geocode, err := s.resolveWithRetry(ctx, job.exif.Geocode, job.language)
+	if err != nil {
+		return err
+	}
+
+	job.exif.Geocode = geocode
+	job.exif = s.geocode.CoarsenExif(job.exif)
+	s.setCache(ctx, job.key, job.exif)
+
+	if !geocode.HasAddress() && len(geocode.Labels) == 0 {
+		s.increaseMetric(ctx, "geocode", "address", "empty")
+		return nil
+	}
+
+	if err = s.amqpClient.PublishJSON(ctx, amqpGeocodeResponse{Item: job.item, Geocode: job.exif.Geocode}, s.amqpExchange, s.geocoder.routingKey); err != nil {
+		return errors.Join(fmt.Errorf("publish amqp message: %w", err), errPublish)
+	}
+
+	s.increaseMetric(ctx, "geocode", "address", "success")
+
+	return nil
+}
+
+func (s Service) resolveWithRetry(ctx context.Context, coordinates model.Geocode, language string) (model.Geocode, error) {
+	var errs []error
+
+	for attempt := range s.geocoder.retries + 1 {
+		if attempt > 0 {
+			timer := time.NewTimer(s.geocoder.retryDelay << (attempt - 1))
+
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return coordinates, errors.Join(append(errs, ctx.Err())...)
+			case <-timer.C:
+			}
+		}
+
+		output, err := s.geocode.Resolve(ctx, coordinates, language)
+		if err == nil {
+			return output, nil
+		}
+
+		errs = append(errs, fmt.Errorf("attempt #%d: %w", attempt+1, err))
+
+		if !geocode.Retryable(err) {
+			break
+		}
+	}
+
+	return coordinates, errors.Join(errs...)
+}
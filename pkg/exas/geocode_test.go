@@ -0,0 +1,316 @@
+package exas
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	absto "github.com/ViBiOh/absto/pkg/model"
+	"github.com/ViBiOh/exas/pkg/geocode"
+	"github.com/ViBiOh/exas/pkg/lru"
+	"github.com/ViBiOh/exas/pkg/model"
+)
+
+// newGeocodeService resolves coordinates with a Nominatim server failing the given number of requests with the status, an invalid payload for 200, then responding an empty address or search.
+// A positive rate limits requests, the ones waiting more than a millisecond being skipped with ErrLimited.
+func newGeocodeService(t *testing.T, failures int64, status int, rate float64) (Service, *atomic.Int64) {
+	t.Helper()
+
+	var calls atomic.Int64
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) <= failures {
+			w.WriteHeader(status)
+			_, _ = w.Write([]byte("{"))
+			return
+		}
+
+		if r.URL.Path == "/search" {
+			_, _ = w.Write([]byte("[]"))
+			return
+		}
+
+		_, _ = w.Write([]byte(`{"address":{}}`))
+	}))
+	t.Cleanup(server.Close)
+
+	config := geocode.Flags(flag.NewFlagSet("test", flag.ContinueOnError), "")
+	config.GeocodeURL = server.URL
+	config.GeocodeCacheSize = 0
+	config.GeocodeRate = rate
+	config.GeocodeMaxWait = time.Millisecond
+
+	geocodeService, err := geocode.New(config, nil, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return Service{
+		geocode: geocodeService,
+		geocoder: &geocoder{
+			jobs:        make(chan geocodeJob, 10),
+			stop:        make(chan struct{}),
+			done:        make(chan struct{}),
+			concurrency: 2,
+			retries:     2,
+			retryDelay:  time.Millisecond,
+		},
+	}, &calls
+}
+
+func TestResolveWithRetry(t *testing.T) {
+	t.Parallel()
+
+	type args struct {
+		failures   int64
+		status     int
+		rate       float64
+		retryDelay time.Duration
+		timeout    time.Duration
+		limited    bool
+	}
+
+	cases := map[string]struct {
+		args      args
+		wantCalls int64
+		wantErr   error
+	}{
+		"success": {
+			args{
+				retryDelay: time.Millisecond,
+				timeout:    time.Second,
+			},
+			1,
+			nil,
+		},
+		"retried": {
+			args{
+				failures:   2,
+				status:     http.StatusInternalServerError,
+				retryDelay: time.Millisecond,
+				timeout:    time.Second,
+			},
+			3,
+			nil,
+		},
+		"exhausted": {
+			args{
+				failures:   5,
+				status:     http.StatusInternalServerError,
+				retryDelay: time.Millisecond,
+				timeout:    time.Second,
+			},
+			3,
+			geocode.ErrProvider,
+		},
+		"cancelled backoff": {
+			args{
+				failures:   5,
+				status:     http.StatusInternalServerError,
+				retryDelay: time.Hour,
+				timeout:    50 * time.Millisecond,
+			},
+			1,
+			context.DeadlineExceeded,
+		},
+		"too many requests": {
+			args{
+				failures:   1,
+				status:     http.StatusTooManyRequests,
+				retryDelay: time.Millisecond,
+				timeout:    time.Second,
+			},
+			2,
+			nil,
+		},
+		"client error": {
+			args{
+				failures:   5,
+				status:     http.StatusBadRequest,
+				retryDelay: time.Hour,
+				timeout:    time.Second,
+			},
+			1,
+			geocode.ErrProvider,
+		},
+		"decode error": {
+			args{
+				failures:   5,
+				status:     http.StatusOK,
+				retryDelay: time.Hour,
+				timeout:    time.Second,
+			},
+			1,
+			geocode.ErrProvider,
+		},
+		"limited": {
+			args{
+				rate:       1e-6,
+				retryDelay: time.Hour,
+				timeout:    time.Second,
+				limited:    true,
+			},
+			1,
+			geocode.ErrLimited,
+		},
+	}
+
+	for intention, tc := range cases {
+		t.Run(intention, func(t *testing.T) {
+			t.Parallel()
+
+			service, calls := newGeocodeService(t, tc.args.failures, tc.args.status, tc.args.rate)
+			service.geocoder.retryDelay = tc.args.retryDelay
+
+			ctx, cancel := context.WithTimeout(context.Background(), tc.args.timeout)
+			defer cancel()
+
+			coordinates := model.Geocode{Latitude: 48.8566, Longitude: 2.3522}
+
+			if tc.args.limited {
+				// the only token of the rate limit is taken
+				if _, err := service.geocode.Resolve(ctx, coordinates, ""); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			_, gotErr := service.resolveWithRetry(ctx, coordinates, "")
+
+			if !errors.Is(gotErr, tc.wantErr) {
+				t.Errorf("resolveWithRetry() error = %v, want %v", gotErr, tc.wantErr)
+			} else if errors.Is(gotErr, context.DeadlineExceeded) && !errors.Is(tc.wantErr, context.DeadlineExceeded) {
+				t.Errorf("resolveWithRetry() error = %v, want no retry", gotErr)
+			}
+
+			if got := calls.Load(); got != tc.wantCalls {
+				t.Errorf("resolveWithRetry() calls = %d, want %d", got, tc.wantCalls)
+			}
+		})
+	}
+}
+
+func TestGeocoder(t *testing.T) {
+	t.Parallel()
+
+	job := geocodeJob{
+		item: absto.Item{Pathname: "/image.jpg"},
+		exif: model.Exif{Geocode: model.Geocode{Latitude: 48.8566, Longitude: 2.3522}},
+	}
+
+	t.Run("close without start", func(t *testing.T) {
+		t.Parallel()
+
+		service, _ := newGeocodeService(t, 0, http.StatusInternalServerError, 0)
+
+		closed := make(chan struct{})
+		go func() {
+			service.geocoder.close()
+			close(closed)
+		}()
+
+		select {
+		case <-closed:
+		case <-time.After(time.Second):
+			t.Fatal("close() blocked without workers")
+		}
+
+		service.Start(context.Background())
+
+		if service.geocoder.enqueue(context.Background(), job) {
+			t.Error("enqueue() = true after close, want false")
+		}
+	})
+
+	t.Run("close while jobs pending", func(t *testing.T) {
+		t.Parallel()
+
+		service, calls := newGeocodeService(t, 0, http.StatusInternalServerError, 0)
+
+		for range 3 {
+			if !service.geocoder.enqueue(context.Background(), job) {
+				t.Fatal("enqueue() = false, want true")
+			}
+		}
+
+		started := make(chan struct{})
+		go func() {
+			close(started)
+			service.Start(context.Background())
+		}()
+
+		<-started
+
+		for !isStarted(service.geocoder) {
+			time.Sleep(time.Millisecond)
+		}
+
+		service.geocoder.close()
+
+		if got := calls.Load(); got != 3 {
+			t.Errorf("close() processed %d jobs, want 3", got)
+		}
+	})
+}
+
+func isStarted(g *geocoder) bool {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	return g.started
+}
+
+func TestResolveGeocodeCache(t *testing.T) {
+	t.Parallel()
+
+	type args struct {
+		failures int64
+		async    bool
+	}
+
+	cases := map[string]struct {
+		args args
+		want bool
+	}{
+		"resolved": {
+			args{},
+			true,
+		},
+		"failed": {
+			args{
+				failures: 1,
+			},
+			false,
+		},
+		"pending": {
+			args{
+				async: true,
+			},
+			false,
+		},
+	}
+
+	for intention, tc := range cases {
+		t.Run(intention, func(t *testing.T) {
+			t.Parallel()
+
+			service, _ := newGeocodeService(t, tc.args.failures, http.StatusInternalServerError, 0)
+			service.cache = memoryCache{lru.New[string, model.Exif](10)}
+
+			if !tc.args.async {
+				service.geocoder = nil
+			}
+
+			exif := model.Exif{Geocode: model.Geocode{Latitude: 48.8566, Longitude: 2.3522}}
+			service.resolveGeocode(context.Background(), absto.Item{Pathname: "/image.jpg"}, "key", exif, options{})
+
+			if _, got := service.getCache(context.Background(), "key", false); got != tc.want {
+				t.Errorf("resolveGeocode() cached = %t, want %t", got, tc.want)
+			}
+		})
+	}
+}
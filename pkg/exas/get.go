@@ -1,10 +1,11 @@
 package exas
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 
-	"github.com/ViBiOh/httputils/v4/pkg/httperror"
+	"github.com/ViBiOh/exas/pkg/model"
 	"github.com/ViBiOh/httputils/v4/pkg/httpjson"
 )
 
@@ -16,20 +17,27 @@ func (s Service) HandleGet(w http.ResponseWriter, r *http.Request) {
 
 	ctx := r.Context()
 
-	reader, err := s.storage.ReadFrom(ctx, r.URL.Path)
+	opts, err := requestOptions(r)
 	if err != nil {
-		httperror.InternalServerError(ctx, w, fmt.Errorf("read from storage: %w", err))
+		s.httpError(ctx, w, "exif", err)
 		return
 	}
-	defer closeWithLog(ctx, reader, "HandleGet", r.URL.Path)
 
-	exif, err := s.get(r.Context(), reader)
+	exif, err := s.getFromStorage(ctx, r.URL.Path, opts)
 	if err != nil {
-		httperror.InternalServerError(ctx, w, err)
-		s.increaseMetric(ctx, "http", "exif", "error")
+		s.httpError(ctx, w, "exif", err)
 		return
 	}
 
 	httpjson.Write(ctx, w, http.StatusOK, exif)
 	s.increaseMetric(ctx, "http", "exif", "success")
 }
+
+func (s Service) getFromStorage(ctx context.Context, pathname string, opts options) (model.Exif, error) {
+	item, err := s.storage.Stat(ctx, pathname)
+	if err != nil {
+		return model.Exif{}, fmt.Errorf("stat from storage: %w", storageError(err))
+	}
+
+	return s.getItem(ctx, item, opts)
+}
@@ -2,7 +2,6 @@ package exas
 
 import (
 	"context"
-	"errors"
 
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/metric"
@@ -26,14 +25,6 @@ func (s Service) handleMetric(ctx context.Context, source, kind string, err erro
 		return
 	}
 
-	switch {
-	case errors.Is(err, errNoAccess):
-		s.increaseMetric(ctx, source, kind, "no_access")
-	case errors.Is(err, errUnmarshal):
-		s.increaseMetric(ctx, source, kind, "unmarshal_error")
-	case errors.Is(err, errExtract):
-		s.increaseMetric(ctx, source, kind, "error")
-	case errors.Is(err, errPublish):
-		s.increaseMetric(ctx, source, kind, "publish_error")
-	}
+	_, code := errorCategory(err)
+	s.increaseMetric(ctx, source, kind, code)
 }
@@ -0,0 +1,59 @@
+package exas
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+
+	"github.com/ViBiOh/exas/pkg/model"
+)
+
+type itemResponse struct {
+	*model.Exif
+	Pathname string `json:"pathname"`
+	Error    string `json:"error,omitempty"`
+	Code     string `json:"code,omitempty"`
+}
+
+type ndjsonWriter struct {
+	encoder *json.Encoder
+	flush   func() error
+	mutex   sync.Mutex
+}
+
+func newNDJSONWriter(w io.Writer) *ndjsonWriter {
+	writer := &ndjsonWriter{
+		encoder: json.NewEncoder(w),
+	}
+
+	if responseWriter, ok := w.(http.ResponseWriter); ok {
+		responseWriter.Header().Set("Content-Type", "application/x-ndjson")
+		responseWriter.Header().Set("Cache-Control", "no-cache")
+		responseWriter.WriteHeader(http.StatusOK)
+
+		writer.flush = http.NewResponseController(responseWriter).Flush
+	}
+
+	return writer
+}
+
+func (n *ndjsonWriter) Write(response itemResponse) error {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+
+	if err := n.encoder.Encode(response); err != nil {
+		return fmt.Errorf("encode: %w", err)
+	}
+
+	if n.flush == nil {
+		return nil
+	}
+
+	if err := n.flush(); err != nil {
+		return fmt.Errorf("flush: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,218 @@
+package exas
+
+import (
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/ViBiOh/exas/pkg/model"
+)
+
+var orientations = map[string]int{
+	"Horizontal (normal)":                 1,
+	"Mirror horizontal":                   2,
+	"Rotate 180":                          3,
+	"Mirror vertical":                     4,
+	"Mirror horizontal and rotate 270 CW": 5,
+	"Rotate 90 CW":                        6,
+	"Mirror horizontal and rotate 90 CW":  7,
+	"Rotate 270 CW":                       8,
+}
+
+func getMetadata(data map[string]any) model.Metadata {
+	return model.Metadata{
+		MimeType: getString(data, "MIMEType"),
+		Camera: model.Camera{
+			Make:  getString(data, "Make"),
+			Model: getString(data, "Model"),
+		},
+		Lens: model.Lens{
+			Make:              getString(data, "LensMake"),
+			Model:             getString(data, "LensModel", "LensID", "Lens"),
+			FocalLength:       getFloat(data, "FocalLength"),
+			FocalLengthIn35mm: getFloat(data, "FocalLengthIn35mmFormat"),
+		},
+		Exposure: model.Exposure{
+			Time:         getFloat(data, "ExposureTime", "ShutterSpeed"),
+			FNumber:      getFloat(data, "FNumber", "Aperture"),
+			Compensation: getFloat(data, "ExposureCompensation"),
+			ISO:          int(getFloat(data, "ISO")),
+		},
+		Dimensions: model.Dimensions{
+			Width:  int(getFloat(data, "ImageWidth", "ExifImageWidth")),
+			Height: int(getFloat(data, "ImageHeight", "ExifImageHeight")),
+		},
+		Duration:    getDuration(data, "Duration", "MediaDuration", "TrackDuration"),
+		Orientation: getOrientation(data),
+		Rating:      int(getFloat(data, "Rating")),
+		Keywords:    getStrings(data, "Keywords", "Subject"),
+		Video:       getVideo(data),
+	}
+}
+
+func isVideo(data map[string]any) bool {
+	return strings.HasPrefix(getString(data, "MIMEType"), "video/")
+}
+
+func getVideo(data map[string]any) model.Video {
+	if !isVideo(data) {
+		return model.Video{}
+	}
+
+	return model.Video{
+		VideoCodec: getString(data, "CompressorID", "VideoCodec", "CompressorName"),
+		AudioCodec: getString(data, "AudioFormat", "AudioCodec"),
+		FrameRate:  getFloat(data, "VideoFrameRate"),
+		Rotation:   int(getFloat(data, "Rotation")),
+	}
+}
+
+func getString(data map[string]any, keys ...string) string {
+	for _, key := range keys {
+		switch value := data[key].(type) {
+		case string:
+			if value = strings.TrimSpace(value); len(value) != 0 {
+				return value
+			}
+		case float64:
+			return strconv.FormatFloat(value, 'f', -1, 64)
+		}
+	}
+
+	return ""
+}
+
+func getStrings(data map[string]any, keys ...string) []string {
+	var output []string
+
+	for _, key := range keys {
+		switch value := data[key].(type) {
+		case string:
+			output = appendUnique(output, strings.TrimSpace(value))
+		case float64:
+			output = appendUnique(output, strconv.FormatFloat(value, 'f', -1, 64))
+		case []any:
+			for _, item := range value {
+				switch typed := item.(type) {
+				case string:
+					output = appendUnique(output, strings.TrimSpace(typed))
+				case float64:
+					output = appendUnique(output, strconv.FormatFloat(typed, 'f', -1, 64))
+				}
+			}
+		}
+	}
+
+	return output
+}
+
+func appendUnique(output []string, value string) []string {
+	if len(value) == 0 {
+		return output
+	}
+
+	if slices.Contains(output, value) {
+		return output
+	}
+
+	return append(output, value)
+}
+
+func getFloat(data map[string]any, keys ...string) float64 {
+	for _, key := range keys {
+		switch value := data[key].(type) {
+		case float64:
+			return value
+		case string:
+			if number, ok := parseNumber(value); ok {
+				return number
+			}
+		}
+	}
+
+	return 0
+}
+
+// parseNumber handles printed values of exiftool, e.g. `1/250`, `+1/3`, `50.0 mm` or `12.5 s`
+func parseNumber(value string) (float64, bool) {
+	value, _, _ = strings.Cut(strings.TrimSpace(value), " ")
+	if len(value) == 0 {
+		return 0, false
+	}
+
+	if numerator, denominator, ok := strings.Cut(value, "/"); ok {
+		top, err := strconv.ParseFloat(numerator, 64)
+		if err != nil {
+			return 0, false
+		}
+
+		bottom, err := strconv.ParseFloat(denominator, 64)
+		if err != nil || bottom == 0 {
+			return 0, false
+		}
+
+		return top / bottom, true
+	}
+
+	number, err := strconv.ParseFloat(value, 64)
+	if err != nil || math.IsNaN(number) || math.IsInf(number, 0) {
+		return 0, false
+	}
+
+	return number, true
+}
+
+func getDuration(data map[string]any, keys ...string) float64 {
+	for _, key := range keys {
+		switch value := data[key].(type) {
+		case float64:
+			return value
+		case string:
+			if duration, ok := parseDuration(value); ok {
+				return duration
+			}
+		}
+	}
+
+	return 0
+}
+
+// parseDuration handles `0:01:23`, `0:01:23 (approx)` and `12.34 s` formats, in seconds
+func parseDuration(value string) (float64, bool) {
+	value, _, _ = strings.Cut(strings.TrimSpace(value), " ")
+
+	if !strings.Contains(value, ":") {
+		return parseNumber(value)
+	}
+
+	var duration float64
+
+	for part := range strings.SplitSeq(value, ":") {
+		number, err := strconv.ParseFloat(part, 64)
+		if err != nil {
+			return 0, false
+		}
+
+		duration = duration*60 + number
+	}
+
+	return duration, true
+}
+
+func getOrientation(data map[string]any) int {
+	switch value := data["Orientation"].(type) {
+	case float64:
+		return int(value)
+	case string:
+		if orientation, ok := orientations[value]; ok {
+			return orientation
+		}
+
+		if orientation, err := strconv.Atoi(value); err == nil {
+			return orientation
+		}
+	}
+
+	return 0
+}
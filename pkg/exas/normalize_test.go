@@ -0,0 +1,103 @@
+package exas
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/ViBiOh/exas/pkg/model"
+)
+
+func TestGetMetadata(t *testing.T) {
+	t.Parallel()
+
+	type args struct {
+		data map[string]any
+	}
+
+	cases := map[string]struct {
+		args args
+		want model.Metadata
+	}{
+		"empty": {
+			args{},
+			model.Metadata{},
+		},
+		"printed": {
+			args{
+				data: map[string]any{
+					"MIMEType":                "image/jpeg",
+					"Make":                    "FUJIFILM",
+					"Model":                   "X-T3",
+					"LensModel":               "XF23mmF2 R WR",
+					"FocalLength":             "23.0 mm",
+					"FocalLengthIn35mmFormat": "35 mm",
+					"ExposureTime":            "1/250",
+					"FNumber":                 2.8,
+					"ExposureCompensation":    "-2/3",
+					"ISO":                     float64(400),
+					"ImageWidth":              float64(6240),
+					"ImageHeight":             float64(4160),
+					"Orientation":             "Rotate 90 CW",
+					"Rating":                  float64(4),
+					"Keywords":                []any{"holiday", "beach"},
+					"Subject":                 "beach",
+				},
+			},
+			model.Metadata{
+				MimeType: "image/jpeg",
+				Camera:   model.Camera{Make: "FUJIFILM", Model: "X-T3"},
+				Lens:     model.Lens{Model: "XF23mmF2 R WR", FocalLength: 23, FocalLengthIn35mm: 35},
+				Exposure: model.Exposure{Time: 0.004, FNumber: 2.8, Compensation: -2.0 / 3, ISO: 400},
+				Dimensions: model.Dimensions{
+					Width:  6240,
+					Height: 4160,
+				},
+				Orientation: 6,
+				Rating:      4,
+				Keywords:    []string{"holiday", "beach"},
+			},
+		},
+		"video": {
+			args{
+				data: map[string]any{
+					"MIMEType":       "video/quicktime",
+					"Duration":       "0:01:23",
+					"CompressorID":   "hvc1",
+					"AudioFormat":    "mp4a",
+					"VideoFrameRate": 29.97,
+					"Rotation":       float64(90),
+				},
+			},
+			model.Metadata{
+				MimeType: "video/quicktime",
+				Duration: 83,
+				Video: model.Video{
+					VideoCodec: "hvc1",
+					AudioCodec: "mp4a",
+					FrameRate:  29.97,
+					Rotation:   90,
+				},
+			},
+		},
+		"short video": {
+			args{
+				data: map[string]any{
+					"Duration": "12.5 s",
+				},
+			},
+			model.Metadata{
+				Duration: 12.5,
+			},
+		},
+	}
+
+	for intention, tc := range cases {
+		t.Run(intention, func(t *testing.T) {
+			t.Parallel()
+
+			if got := getMetadata(tc.args.data); !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("getMetadata() = %+v, want %+v", got, tc.want)
+			}
+		})
+	}
+}
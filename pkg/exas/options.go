@@ -0,0 +1,133 @@
+package exas
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	absto "github.com/ViBiOh/absto/pkg/model"
+	"github.com/ViBiOh/exas/pkg/geocode"
+	"github.com/ViBiOh/httputils/v4/pkg/query"
+)
+
+// options are the per-request settings of an extraction, the ones changing the output are part of the cache key
+type options struct {
+	language string
+	binary   []string
+	tags     []string
+	exclude  []string
+	groups   bool
+	numeric  bool
+	refresh  bool
+}
+
+// rawOptions are the extraction options as received, in AMQP messages fields or HTTP query params
+type rawOptions struct {
+	Language string   `json:"language"`
+	Binary   []string `json:"binary"`
+	Tags     []string `json:"tags"`
+	Exclude  []string `json:"exclude"`
+	Groups   bool     `json:"groups"`
+	Numeric  bool     `json:"numeric"`
+}
+
+func requestOptions(r *http.Request) (options, error) {
+	params := r.URL.Query()
+
+	opts, err := rawOptions{
+		Language: r.Header.Get("Accept-Language"),
+		Binary:   params["binary"],
+		Tags:     params["tags"],
+		Exclude:  params["exclude"],
+		Groups:   query.GetBool(r, "groups"),
+		Numeric:  query.GetBool(r, "numeric"),
+	}.options()
+	if err != nil {
+		return opts, err
+	}
+
+	opts.refresh = query.GetBool(r, "refresh")
+
+	return opts, nil
+}
+
+func (o rawOptions) options() (output options, err error) {
+	if output.binary, err = parseTags(o.Binary, isTagName); err != nil {
+		return output, fmt.Errorf("binary %w", err)
+	}
+
+	if output.tags, err = parseTags(o.Tags, isTagPattern); err != nil {
+		return output, fmt.Errorf("tags %w", err)
+	}
+
+	if output.exclude, err = parseTags(o.Exclude, isTagPattern); err != nil {
+		return output, fmt.Errorf("exclude %w", err)
+	}
+
+	output.language = geocode.ParseLanguage(o.Language)
+	output.groups = o.Groups
+	output.numeric = o.Numeric
+
+	return output, nil
+}
+
+// parseTags splits the comma separated values, validated because each one becomes an exiftool argument
+func parseTags(values []string, valid func(string) bool) ([]string, error) {
+	tags := splitParams(values)
+
+	for _, tag := range tags {
+		if !valid(tag) {
+			return nil, fmt.Errorf("`%s`: %w", tag, errInvalidTag)
+		}
+	}
+
+	slices.Sort(tags)
+
+	return slices.Compact(tags), nil
+}
+
+// hasDataArgs reports if the data is extracted with arguments of the request, instead of being the one used for normalization
+func (o options) hasDataArgs() bool {
+	return len(o.tags) != 0 || len(o.exclude) != 0 || o.groups || o.numeric
+}
+
+func (o options) dataArgs() []string {
+	var args []string
+
+	if o.groups {
+		// duplicates are only distinguishable with their group
+		args = append(args, "-G1", "-a")
+	}
+
+	if o.numeric {
+		args = append(args, "-n")
+	}
+
+	for _, tag := range o.tags {
+		args = append(args, "-"+tag)
+	}
+
+	for _, tag := range o.exclude {
+		args = append(args, "--"+tag)
+	}
+
+	return args
+}
+
+func (o options) cacheKey(key string) string {
+	if len(o.language) != 0 {
+		key += "-" + strings.ReplaceAll(o.language, ",", "_")
+	}
+
+	if len(o.binary) != 0 {
+		key += "-" + strings.ReplaceAll(strings.Join(o.binary, "_"), ":", "_")
+	}
+
+	if o.hasDataArgs() {
+		key += "-" + absto.ID(strings.Join(o.tags, ",")+"|"+strings.Join(o.exclude, ",")+"|"+strconv.FormatBool(o.groups)+"|"+strconv.FormatBool(o.numeric))
+	}
+
+	return key
+}
@@ -0,0 +1,146 @@
+package exas
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestRawOptions(t *testing.T) {
+	t.Parallel()
+
+	type args struct {
+		language string
+		binary   []string
+	}
+
+	cases := map[string]struct {
+		args    args
+		want    string
+		wantErr error
+	}{
+		"empty": {
+			args{},
+			"item",
+			nil,
+		},
+		"binary": {
+			args{
+				language: "fr",
+				binary:   []string{"ThumbnailImage,ICC_Profile", "ThumbnailImage"},
+			},
+			"item-fr-ICC_Profile_ThumbnailImage",
+			nil,
+		},
+		"grouped option name": {
+			args{
+				binary: []string{"XMP:o"},
+			},
+			"item-XMP_o",
+			nil,
+		},
+		"option": {
+			args{
+				binary: []string{"ThumbnailImage,execute"},
+			},
+			"",
+			errInvalidTag,
+		},
+		"numbered option": {
+			args{
+				binary: []string{"Execute42"},
+			},
+			"",
+			errInvalidTag,
+		},
+		"invalid binary": {
+			args{
+				binary: []string{"ThumbnailImage\n-o"},
+			},
+			"",
+			errInvalidTag,
+		},
+	}
+
+	for intention, tc := range cases {
+		t.Run(intention, func(t *testing.T) {
+			t.Parallel()
+
+			got, gotErr := rawOptions{Language: tc.args.language, Binary: tc.args.binary}.options()
+
+			if !errors.Is(gotErr, tc.wantErr) {
+				t.Errorf("options() error = %v, want %v", gotErr, tc.wantErr)
+			} else if gotErr == nil && got.cacheKey("item") != tc.want {
+				t.Errorf("options() cache key = `%s`, want `%s`", got.cacheKey("item"), tc.want)
+			}
+		})
+	}
+}
+
+func TestDataArgs(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]struct {
+		args    rawOptions
+		want    []string
+		wantErr error
+	}{
+		"default": {
+			rawOptions{},
+			nil,
+			nil,
+		},
+		"all": {
+			rawOptions{
+				Tags:    []string{"Make,Model", "GPS*"},
+				Exclude: []string{"MakerNotes:all"},
+				Groups:  true,
+				Numeric: true,
+			},
+			[]string{"-G1", "-a", "-n", "-GPS*", "-Make", "-Model", "--MakerNotes:all"},
+			nil,
+		},
+		"file tag": {
+			rawOptions{
+				Tags: []string{"FileName"},
+			},
+			[]string{"-FileName"},
+			nil,
+		},
+		"option tag": {
+			rawOptions{
+				Tags: []string{"Make,execute"},
+			},
+			nil,
+			errInvalidTag,
+		},
+		"option exclude": {
+			rawOptions{
+				Exclude: []string{"W"},
+			},
+			nil,
+			errInvalidTag,
+		},
+		"tags from file": {
+			rawOptions{
+				Tags: []string{"TagsFromFile"},
+			},
+			nil,
+			errInvalidTag,
+		},
+	}
+
+	for intention, tc := range cases {
+		t.Run(intention, func(t *testing.T) {
+			t.Parallel()
+
+			opts, err := tc.args.options()
+
+			if !errors.Is(err, tc.wantErr) {
+				t.Errorf("options() error = %v, want %v", err, tc.wantErr)
+			} else if got := opts.dataArgs(); !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("dataArgs() = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
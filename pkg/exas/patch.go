@@ -0,0 +1,278 @@
+package exas
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"net/http"
+	"os"
+	"path"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	absto "github.com/ViBiOh/absto/pkg/model"
+	"github.com/ViBiOh/exas/pkg/model"
+	"github.com/ViBiOh/httputils/v4/pkg/httpjson"
+	"github.com/ViBiOh/httputils/v4/pkg/telemetry"
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+var (
+	errInvalidTag = errors.New("invalid tag")
+	tagNameRegex  = regexp.MustCompile(`^\w[\w-]*(?::\w[\w-]*)*$`)
+
+	// fileTags are the lowercased pseudo-tags of exiftool acting on the file itself (e.g. moving, linking or reading another file), never written from a request
+	fileTags = map[string]struct{}{
+		"filename":        {},
+		"directory":       {},
+		"hardlink":        {},
+		"symlink":         {},
+		"testname":        {},
+		"filepermissions": {},
+		"filemodifydate":  {},
+		"filecreatedate":  {},
+		"fileuserid":      {},
+		"filegroupid":     {},
+		"geotag":          {},
+		"geosync":         {},
+		"geotime":         {},
+	}
+
+	// fileGroups are the lowercased groups of these pseudo-tags
+	fileGroups = map[string]struct{}{
+		"system": {},
+		"file":   {},
+	}
+)
+
+type amqpUpdateRequest struct {
+	Tags map[string]any `json:"tags"`
+	Item absto.Item     `json:"item"`
+	rawOptions
+}
+
+func (s Service) HandlePatch(w http.ResponseWriter, r *http.Request) {
+	if !s.storage.Enabled() {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	ctx := r.Context()
+
+	defer closeWithLog(ctx, r.Body, "HandlePatch", "input")
+
+	tags, err := httpjson.Parse[map[string]any](r)
+	if err != nil {
+		s.httpError(ctx, w, "update", fmt.Errorf("parse tags: %w: %w", errUnmarshal, err))
+		return
+	}
+
+	opts, err := requestOptions(r)
+	if err != nil {
+		s.httpError(ctx, w, "update", err)
+		return
+	}
+
+	exif, err := s.update(ctx, r.URL.Path, tags, opts)
+	if err != nil {
+		s.httpError(ctx, w, "update", err)
+		return
+	}
+
+	s.increaseMetric(ctx, "http", "update", "success")
+	httpjson.Write(ctx, w, http.StatusOK, exif)
+}
+
+func (s Service) AmqpUpdateHandler(ctx context.Context, message amqp.Delivery) (err error) {
+	defer func() { s.handleMetric(ctx, "amqp", "update", err) }()
+
+	if !s.storage.Enabled() {
+		return errNoAccess
+	}
+
+	ctx, end := telemetry.StartSpan(ctx, s.tracer, "amqp_update")
+	defer end(&err)
+
+	var request amqpUpdateRequest
+	if err = json.Unmarshal(message.Body, &request); err != nil {
+		return errors.Join(fmt.Errorf("decode: %w", err), errUnmarshal)
+	}
+
+	opts, err := request.options()
+	if err != nil {
+		return errors.Join(fmt.Errorf("options: %w", err), errUnmarshal)
+	}
+
+	exif, err := s.update(ctx, request.Item.Pathname, request.Tags, opts)
+	if err != nil {
+		return errors.Join(fmt.Errorf("update exif: %w", err), errExtract)
+	}
+
+	return s.publish(ctx, request.Item, exif)
+}
+
+// update writes tags into a local copy of the file, then replaces the stored file through a temporary object.
+func (s Service) update(ctx context.Context, pathname string, tags map[string]any, opts options) (exif model.Exif, err error) {
+	ctx, end := telemetry.StartSpan(ctx, s.tracer, "update")
+	defer end(&err)
+
+	args, err := exiftoolWriteArgs(tags)
+	if err != nil {
+		return exif, err
+	}
+
+	reader, err := s.storage.ReadFrom(ctx, pathname)
+	if err != nil {
+		return exif, fmt.Errorf("read from storage: %w", storageError(err))
+	}
+
+	name, err := writeTemp(reader, path.Ext(pathname))
+	closeWithLog(ctx, reader, "update", pathname)
+
+	if err != nil {
+		return exif, fmt.Errorf("write input: %w", err)
+	}
+	defer removeWithLog(ctx, name)
+
+	buffer := bufferPool.Get().(*bytes.Buffer)
+	defer bufferPool.Put(buffer)
+
+	buffer.Reset()
+
+	if err = s.exiftool.run(ctx, buffer, append(args, name)...); err != nil {
+		return exif, fmt.Errorf("write tags: %w", err)
+	}
+
+	if err = s.replace(ctx, pathname, name); err != nil {
+		return exif, fmt.Errorf("replace: %w", err)
+	}
+
+	exif, err = s.extract(ctx, name, opts)
+	if err != nil {
+		return exif, err
+	}
+
+	item, err := s.storage.Stat(ctx, pathname)
+	if err != nil {
+		return s.resolveGeocode(ctx, absto.Item{}, "", exif, opts), nil
+	}
+
+	return s.resolveGeocode(ctx, item, opts.cacheKey(itemCacheKey(item)), exif, opts), nil
+}
+
+func (s Service) replace(ctx context.Context, pathname, name string) error {
+	file, err := os.Open(name)
+	if err != nil {
+		return fmt.Errorf("open: %w", err)
+	}
+	defer closeWithLog(ctx, file, "replace", name)
+
+	info, err := file.Stat()
+	if err != nil {
+		return fmt.Errorf("stat: %w", err)
+	}
+
+	tempName := path.Join(path.Dir(pathname), "."+path.Base(pathname)+".exas")
+
+	if err = s.storage.WriteTo(ctx, tempName, file, absto.WriteOpts{Size: info.Size()}); err != nil {
+		return fmt.Errorf("write temporary object: %w", err)
+	}
+
+	if err = s.storage.Rename(ctx, tempName, pathname); err != nil {
+		if removeErr := s.storage.RemoveAll(ctx, tempName); removeErr != nil {
+			err = errors.Join(err, fmt.Errorf("remove temporary object: %w", removeErr))
+		}
+
+		return fmt.Errorf("rename temporary object: %w", err)
+	}
+
+	return nil
+}
+
+// isTagName reports if the tag is a name, optionally grouped, that exiftool won't mistake for an option
+func isTagName(tag string) bool {
+	return tagNameRegex.MatchString(tag) && !isExiftoolOption(tag)
+}
+
+func exiftoolWriteArgs(tags map[string]any) ([]string, error) {
+	if len(tags) == 0 {
+		return nil, fmt.Errorf("no tag to write: %w", errInvalidTag)
+	}
+
+	args := []string{"-overwrite_original"}
+
+	for _, tag := range slices.Sorted(maps.Keys(tags)) {
+		if !isTagName(tag) || isFileTag(tag) {
+			return nil, fmt.Errorf("name `%s`: %w", tag, errInvalidTag)
+		}
+
+		values, err := tagValues(tags[tag])
+		if err != nil {
+			return nil, fmt.Errorf("value of `%s`: %w", tag, err)
+		}
+
+		for _, value := range values {
+			args = append(args, "-"+tag+"="+value)
+		}
+	}
+
+	return args, nil
+}
+
+// isFileTag checks the name and the groups of the tag, with or without their family number (e.g. `1System`)
+func isFileTag(tag string) bool {
+	parts := strings.Split(strings.ToLower(tag), ":")
+
+	if _, ok := fileTags[parts[len(parts)-1]]; ok {
+		return true
+	}
+
+	for _, group := range parts[:len(parts)-1] {
+		if _, ok := fileGroups[strings.TrimLeft(group, "0123456789")]; ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+func tagValues(value any) ([]string, error) {
+	switch typed := value.(type) {
+	case nil:
+		return []string{""}, nil
+	case []any:
+		var output []string
+
+		for _, item := range typed {
+			if _, ok := item.([]any); ok {
+				return nil, fmt.Errorf("nested list: %w", errInvalidTag)
+			}
+
+			values, err := tagValues(item)
+			if err != nil {
+				return nil, err
+			}
+
+			output = append(output, values...)
+		}
+
+		return output, nil
+	case string:
+		if strings.ContainsAny(typed, "\r\n") {
+			return nil, fmt.Errorf("multiline value: %w", errInvalidTag)
+		}
+
+		return []string{typed}, nil
+	case float64:
+		return []string{strconv.FormatFloat(typed, 'f', -1, 64)}, nil
+	case bool:
+		return []string{strconv.FormatBool(typed)}, nil
+	default:
+		return nil, fmt.Errorf("unhandled type `%T`: %w", value, errInvalidTag)
+	}
+}
@@ -0,0 +1,170 @@
+package exas
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/ViBiOh/absto/pkg/filesystem"
+)
+
+func TestExiftoolWriteArgs(t *testing.T) {
+	t.Parallel()
+
+	type args struct {
+		tags map[string]any
+	}
+
+	cases := map[string]struct {
+		args    args
+		want    []string
+		wantErr error
+	}{
+		"empty": {
+			args{},
+			nil,
+			errInvalidTag,
+		},
+		"simple": {
+			args{
+				tags: map[string]any{
+					"DateTimeOriginal": "2024:01:02 03:04:05",
+					"GPSLatitude":      48.8566,
+					"Keywords":         []any{"holiday", "beach"},
+					"XMP:Rating":       nil,
+				},
+			},
+			[]string{"-overwrite_original", "-DateTimeOriginal=2024:01:02 03:04:05", "-GPSLatitude=48.8566", "-Keywords=holiday", "-Keywords=beach", "-XMP:Rating="},
+			nil,
+		},
+		"option injection": {
+			args{
+				tags: map[string]any{
+					"-delete_original": true,
+				},
+			},
+			nil,
+			errInvalidTag,
+		},
+		"option name": {
+			args{
+				tags: map[string]any{
+					"tagsFromFile": "/etc/passwd",
+				},
+			},
+			nil,
+			errInvalidTag,
+		},
+		"file name": {
+			args{
+				tags: map[string]any{
+					"Comment":   "hello",
+					"Directory": "/etc/cron.d",
+				},
+			},
+			nil,
+			errInvalidTag,
+		},
+		"symlink": {
+			args{
+				tags: map[string]any{
+					"symlink": "/any/path",
+				},
+			},
+			nil,
+			errInvalidTag,
+		},
+		"qualified file name": {
+			args{
+				tags: map[string]any{
+					"System:FileName": "cron",
+				},
+			},
+			nil,
+			errInvalidTag,
+		},
+		"file group": {
+			args{
+				tags: map[string]any{
+					"1File:MIMEType": "text/plain",
+				},
+			},
+			nil,
+			errInvalidTag,
+		},
+		"geotag": {
+			args{
+				tags: map[string]any{
+					"Geotag": "/etc/passwd",
+				},
+			},
+			nil,
+			errInvalidTag,
+		},
+		"multiline": {
+			args{
+				tags: map[string]any{
+					"Comment": "hello\n-delete_original",
+				},
+			},
+			nil,
+			errInvalidTag,
+		},
+	}
+
+	for intention, tc := range cases {
+		t.Run(intention, func(t *testing.T) {
+			t.Parallel()
+
+			got, gotErr := exiftoolWriteArgs(tc.args.tags)
+
+			if !errors.Is(gotErr, tc.wantErr) {
+				t.Errorf("exiftoolWriteArgs() error = `%v`, want `%v`", gotErr, tc.wantErr)
+			}
+
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("exiftoolWriteArgs() = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	t.Parallel()
+
+	directory := t.TempDir()
+	writeJPEG(t, filepath.Join(directory, "image.jpg"))
+
+	storage, err := filesystem.New(directory)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	service := Service{storage: storage, exiftool: newLocalExiftool(t)}
+	ctx := context.Background()
+
+	exif, err := service.update(ctx, "/image.jpg", map[string]any{"Artist": "exas"}, options{})
+	if err != nil {
+		t.Fatalf("update() error = %v", err)
+	}
+
+	if got := exif.Data["Artist"]; got != "exas" {
+		t.Errorf("update() Artist = %v, want `exas`", got)
+	}
+
+	item, err := storage.Stat(ctx, "/image.jpg")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	exif, err = service.getItem(ctx, item, options{})
+	if err != nil {
+		t.Fatalf("getItem() error = %v", err)
+	}
+
+	if got := exif.Data["Artist"]; got != "exas" {
+		t.Errorf("getItem() Artist = %v, want `exas`", got)
+	}
+}
@@ -3,7 +3,6 @@ package exas
 import (
 	"net/http"
 
-	"github.com/ViBiOh/httputils/v4/pkg/httperror"
 	"github.com/ViBiOh/httputils/v4/pkg/httpjson"
 )
 
@@ -12,10 +11,15 @@ func (s Service) HandlePost(w http.ResponseWriter, r *http.Request) {
 
 	defer closeWithLog(ctx, r.Body, "handlePost", "input")
 
-	exif, err := s.get(ctx, r.Body)
+	opts, err := requestOptions(r)
 	if err != nil {
-		s.increaseMetric(ctx, "http", "exif", "error")
-		httperror.InternalServerError(ctx, w, err)
+		s.httpError(ctx, w, "exif", err)
+		return
+	}
+
+	exif, err := s.getContent(ctx, s.limitPayload(w, r), opts)
+	if err != nil {
+		s.httpError(ctx, w, "exif", err)
 		return
 	}
 
@@ -0,0 +1,243 @@
+package exas
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/jpeg"
+	"log/slog"
+	"net/http"
+	"path"
+	"slices"
+	"time"
+
+	absto "github.com/ViBiOh/absto/pkg/model"
+	"github.com/ViBiOh/httputils/v4/pkg/telemetry"
+)
+
+const previewQuality = 90
+
+var (
+	errNoPreview = errors.New("no embedded preview")
+
+	// previewTags are the embedded JPEG images, from the usually largest to the smallest
+	previewTags = []string{
+		"JpgFromRaw",
+		"PreviewImage",
+		"OtherImage",
+		"ThumbnailImage",
+	}
+)
+
+func (s Service) HandlePreview(w http.ResponseWriter, r *http.Request) {
+	if !s.storage.Enabled() {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	ctx := r.Context()
+
+	tag, err := previewTag(r)
+	if err != nil {
+		s.httpError(ctx, w, "preview", err)
+		return
+	}
+
+	item, err := s.storage.Stat(ctx, "/"+r.PathValue("path"))
+	if err != nil {
+		s.httpError(ctx, w, "preview", fmt.Errorf("stat from storage: %w", storageError(err)))
+		return
+	}
+
+	reader, err := s.storage.ReadFrom(ctx, item.Pathname)
+	if err != nil {
+		s.httpError(ctx, w, "preview", fmt.Errorf("read from storage: %w", storageError(err)))
+		return
+	}
+
+	name, err := writeTemp(reader, path.Ext(item.Pathname))
+	closeWithLog(ctx, reader, "HandlePreview", item.Pathname)
+
+	if err != nil {
+		s.httpError(ctx, w, "preview", fmt.Errorf("write input: %w", err))
+		return
+	}
+	defer removeWithLog(ctx, name)
+
+	// conditional requests are answered by http.ServeContent, with the ETag set
+	w.Header().Set("ETag", `"`+absto.ID(item.String()+tag)+`"`)
+	w.Header().Set("Cache-Control", "private, no-cache")
+
+	s.servePreview(w, r, name, tag, item.Date)
+}
+
+func (s Service) HandlePostPreview(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	defer closeWithLog(ctx, r.Body, "HandlePostPreview", "input")
+
+	tag, err := previewTag(r)
+	if err != nil {
+		s.httpError(ctx, w, "preview", err)
+		return
+	}
+
+	name, err := writeTemp(s.limitPayload(w, r), "")
+	if err != nil {
+		s.httpError(ctx, w, "preview", fmt.Errorf("write input: %w", err))
+		return
+	}
+	defer removeWithLog(ctx, name)
+
+	w.Header().Set("Cache-Control", "no-store")
+
+	s.servePreview(w, r, name, tag, time.Time{})
+}
+
+func (s Service) servePreview(w http.ResponseWriter, r *http.Request, name, tag string, modTime time.Time) {
+	ctx := r.Context()
+
+	content, err := s.preview(ctx, name, tag)
+	if err != nil {
+		w.Header().Del("ETag")
+		s.httpError(ctx, w, "preview", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "image/jpeg")
+	http.ServeContent(w, r, "", modTime, bytes.NewReader(content))
+
+	s.increaseMetric(ctx, "http", "preview", "success")
+}
+
+func previewTag(r *http.Request) (string, error) {
+	tag := r.URL.Query().Get("tag")
+	if len(tag) != 0 && !slices.Contains(previewTags, tag) {
+		return "", fmt.Errorf("name `%s`: %w", tag, errInvalidTag)
+	}
+
+	return tag, nil
+}
+
+// preview extracts the requested embedded image, or the largest one, rotated according to the Orientation of the file
+func (s Service) preview(ctx context.Context, name, tag string) (content []byte, err error) {
+	ctx, end := telemetry.StartSpan(ctx, s.tracer, "preview")
+	defer end(&err)
+
+	data, err := s.readTags(ctx, name)
+	if err != nil {
+		return nil, fmt.Errorf("read tags: %w", err)
+	}
+
+	if tag = choosePreview(data, tag); len(tag) == 0 {
+		return nil, errNoPreview
+	}
+
+	var buffer bytes.Buffer
+
+	if err = s.exiftool.run(ctx, &buffer, "-b", "-"+tag, name); err != nil {
+		return nil, fmt.Errorf("extract `%s`: %w", tag, err)
+	}
+
+	content = buffer.Bytes()
+
+	if orientation := getOrientation(data); orientation > 1 {
+		rotated, err := rotateJPEG(content, orientation)
+		if err != nil {
+			slog.LogAttrs(ctx, slog.LevelWarn, "rotate preview", slog.String("tag", tag), slog.Any("error", err))
+			return content, nil
+		}
+
+		return rotated, nil
+	}
+
+	return content, nil
+}
+
+// choosePreview returns the requested tag if present, or else the largest preview, from the `(Binary data 1234 bytes, ...)` placeholders of exiftool
+func choosePreview(data map[string]any, requested string) string {
+	var output string
+	var size int
+
+	for _, tag := range previewTags {
+		if len(requested) != 0 && tag != requested {
+			continue
+		}
+
+		if current, ok := binarySize(data[tag]); ok && current > size {
+			output = tag
+			size = current
+		}
+	}
+
+	return output
+}
+
+func rotateJPEG(content []byte, orientation int) ([]byte, error) {
+	source, err := jpeg.Decode(bytes.NewReader(content))
+	if err != nil {
+		return nil, fmt.Errorf("decode: %w", err)
+	}
+
+	var output bytes.Buffer
+
+	if err = jpeg.Encode(&output, orient(source, orientation), &jpeg.Options{Quality: previewQuality}); err != nil {
+		return nil, fmt.Errorf("encode: %w", err)
+	}
+
+	return output.Bytes(), nil
+}
+
+// orient applies the transformation of an EXIF Orientation value, from 2 to 8, for the image to be displayed upright.
+// Pixels are copied between the RGBA buffers, decoded JPEGs being converted once by the fast paths of draw.
+func orient(source image.Image, orientation int) image.Image {
+	input, ok := source.(*image.RGBA)
+	if !ok {
+		input = image.NewRGBA(image.Rect(0, 0, source.Bounds().Dx(), source.Bounds().Dy()))
+		draw.Draw(input, input.Bounds(), source, source.Bounds().Min, draw.Src)
+	}
+
+	bounds := input.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+
+	if orientation >= 5 {
+		width, height = height, width
+	}
+
+	output := image.NewRGBA(image.Rect(0, 0, width, height))
+
+	for y := range bounds.Dy() {
+		row := input.Pix[input.PixOffset(bounds.Min.X, bounds.Min.Y+y):]
+
+		for x := range bounds.Dx() {
+			var targetX, targetY int
+
+			switch orientation {
+			case 2:
+				targetX, targetY = width-1-x, y
+			case 3:
+				targetX, targetY = width-1-x, height-1-y
+			case 4:
+				targetX, targetY = x, height-1-y
+			case 5:
+				targetX, targetY = y, x
+			case 6:
+				targetX, targetY = width-1-y, x
+			case 7:
+				targetX, targetY = width-1-y, height-1-x
+			case 8:
+				targetX, targetY = y, height-1-x
+			default:
+				targetX, targetY = x, y
+			}
+
+			offset := output.PixOffset(targetX, targetY)
+			copy(output.Pix[offset:offset+4], row[x*4:x*4+4])
+		}
+	}
+
+	return output
+}
@@ -0,0 +1,239 @@
+package exas
+
+import (
+	"context"
+	"image"
+	"image/color"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	absto "github.com/ViBiOh/absto/pkg/model"
+)
+
+func TestChoosePreview(t *testing.T) {
+	t.Parallel()
+
+	data := map[string]any{
+		"ThumbnailImage": "(Binary data 5120 bytes, use -b option to extract)",
+		"PreviewImage":   "(Binary data 1048576 bytes, use -b option to extract)",
+		"Make":           "Canon",
+	}
+
+	cases := map[string]struct {
+		args string
+		want string
+	}{
+		"largest": {
+			"",
+			"PreviewImage",
+		},
+		"requested": {
+			"ThumbnailImage",
+			"ThumbnailImage",
+		},
+		"missing": {
+			"JpgFromRaw",
+			"",
+		},
+	}
+
+	for intention, tc := range cases {
+		t.Run(intention, func(t *testing.T) {
+			t.Parallel()
+
+			if got := choosePreview(data, tc.args); got != tc.want {
+				t.Errorf("choosePreview() = `%s`, want `%s`", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestOrient(t *testing.T) {
+	t.Parallel()
+
+	red := color.NRGBA{R: 255, A: 255}
+
+	// 2x1 image with a red pixel on the left, not starting at the origin
+	source := image.NewNRGBA(image.Rect(0, 0, 3, 1)).SubImage(image.Rect(1, 0, 3, 1)).(*image.NRGBA)
+	source.Set(1, 0, red)
+
+	type args struct {
+		orientation int
+	}
+
+	cases := map[string]struct {
+		args     args
+		wantSize image.Point
+		wantRed  image.Point
+	}{
+		"mirror horizontal": {
+			args{orientation: 2},
+			image.Pt(2, 1),
+			image.Pt(1, 0),
+		},
+		"rotate 180": {
+			args{orientation: 3},
+			image.Pt(2, 1),
+			image.Pt(1, 0),
+		},
+		"mirror vertical": {
+			args{orientation: 4},
+			image.Pt(2, 1),
+			image.Pt(0, 0),
+		},
+		"transpose": {
+			args{orientation: 5},
+			image.Pt(1, 2),
+			image.Pt(0, 0),
+		},
+		"rotate 90 CW": {
+			args{orientation: 6},
+			image.Pt(1, 2),
+			image.Pt(0, 0),
+		},
+		"transverse": {
+			args{orientation: 7},
+			image.Pt(1, 2),
+			image.Pt(0, 1),
+		},
+		"rotate 270 CW": {
+			args{orientation: 8},
+			image.Pt(1, 2),
+			image.Pt(0, 1),
+		},
+	}
+
+	for intention, tc := range cases {
+		t.Run(intention, func(t *testing.T) {
+			t.Parallel()
+
+			got := orient(source, tc.args.orientation)
+
+			if size := got.Bounds().Size(); size != tc.wantSize {
+				t.Errorf("orient() size = %v, want %v", size, tc.wantSize)
+			}
+
+			if pixel := color.NRGBAModel.Convert(got.At(tc.wantRed.X, tc.wantRed.Y)); pixel != red {
+				t.Errorf("orient() pixel at %v = %v, want red", tc.wantRed, pixel)
+			}
+		})
+	}
+}
+
+func TestHandlePreview(t *testing.T) {
+	t.Parallel()
+
+	service := newScanService(t)
+	withPreview := newScanService(t)
+
+	if err := os.WriteFile(withPreview.exiftool.path+".preview", nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	item, err := withPreview.storage.Stat(context.Background(), "/a.jpg")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	etag := `"` + absto.ID(item.String()) + `"`
+
+	type args struct {
+		service     Service
+		target      string
+		ifNoneMatch string
+	}
+
+	cases := map[string]struct {
+		args       args
+		want       string
+		wantStatus int
+	}{
+		"no preview": {
+			args{
+				service: service,
+				target:  "/preview/a.jpg",
+			},
+			`"code":"not_found"`,
+			http.StatusNotFound,
+		},
+		"invalid tag": {
+			args{
+				service: service,
+				target:  "/preview/a.jpg?tag=Make",
+			},
+			`"code":"invalid_tag"`,
+			http.StatusBadRequest,
+		},
+		"missing": {
+			args{
+				service: withPreview,
+				target:  "/preview/missing.jpg",
+			},
+			`"code":"not_found"`,
+			http.StatusNotFound,
+		},
+		"preview": {
+			args{
+				service: withPreview,
+				target:  "/preview/a.jpg",
+			},
+			"preview",
+			http.StatusOK,
+		},
+		"not modified": {
+			args{
+				service:     withPreview,
+				target:      "/preview/a.jpg",
+				ifNoneMatch: `"other", W/` + etag,
+			},
+			"",
+			http.StatusNotModified,
+		},
+		"any": {
+			args{
+				service:     withPreview,
+				target:      "/preview/a.jpg",
+				ifNoneMatch: "*",
+			},
+			"",
+			http.StatusNotModified,
+		},
+		"modified": {
+			args{
+				service:     withPreview,
+				target:      "/preview/a.jpg",
+				ifNoneMatch: `"other"`,
+			},
+			"preview",
+			http.StatusOK,
+		},
+	}
+
+	for intention, tc := range cases {
+		t.Run(intention, func(t *testing.T) {
+			t.Parallel()
+
+			mux := http.NewServeMux()
+			mux.HandleFunc("GET /preview/{path...}", tc.args.service.HandlePreview)
+
+			request := httptest.NewRequest(http.MethodGet, tc.args.target, nil)
+			if len(tc.args.ifNoneMatch) != 0 {
+				request.Header.Set("If-None-Match", tc.args.ifNoneMatch)
+			}
+
+			writer := httptest.NewRecorder()
+			mux.ServeHTTP(writer, request)
+
+			if writer.Code != tc.wantStatus {
+				t.Errorf("HandlePreview() status = %d, want %d", writer.Code, tc.wantStatus)
+			} else if !strings.Contains(writer.Body.String(), tc.want) {
+				t.Errorf("HandlePreview() = `%s`, want `%s`", writer.Body.String(), tc.want)
+			} else if got := writer.Header().Get("ETag"); writer.Code < http.StatusBadRequest && got != etag {
+				t.Errorf("HandlePreview() ETag = `%s`, want `%s`", got, etag)
+			}
+		})
+	}
+}
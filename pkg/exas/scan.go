@@ -0,0 +1,136 @@
+package exas
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+
+	absto "github.com/ViBiOh/absto/pkg/model"
+	"github.com/ViBiOh/httputils/v4/pkg/concurrent"
+	"github.com/ViBiOh/httputils/v4/pkg/query"
+)
+
+var errNoPublisher = errors.New("no amqp client configured for publishing")
+
+func (s Service) HandleScan(w http.ResponseWriter, r *http.Request) {
+	if !s.storage.Enabled() {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	ctx := r.Context()
+
+	publish := query.GetBool(r, "publish")
+	if publish && s.amqpClient == nil {
+		s.httpError(ctx, w, "scan", errNoPublisher)
+		return
+	}
+
+	extensions := s.scanExtensions
+	if values := r.URL.Query()["extension"]; len(values) > 0 {
+		extensions = normalizeExtensions(values)
+	}
+
+	opts, err := requestOptions(r)
+	if err != nil {
+		s.httpError(ctx, w, "scan", err)
+		return
+	}
+
+	directory := "/" + r.PathValue("dir")
+
+	if err = s.scan(ctx, directory, extensions, publish, opts, w); err != nil {
+		slog.LogAttrs(ctx, slog.LevelError, "scan", slog.String("dir", directory), slog.Any("error", err))
+	}
+}
+
+// Scan extracts every file of the directory matching given extensions and writes results as NDJSON to the output.
+// When `publish` is set, each result is sent to the AMQP exchange as the `{"item", "exif"}` message of an AMQP extraction,
+// and the NDJSON lines only acknowledge it with the `pathname`, and the `error` and `code` of failures. When `refresh` is set, cache is bypassed.
+func (s Service) Scan(ctx context.Context, directory string, extensions []string, publish, refresh bool, output io.Writer) error {
+	return s.scan(ctx, directory, extensions, publish, options{refresh: refresh}, output)
+}
+
+func (s Service) scan(ctx context.Context, directory string, extensions []string, publish bool, opts options, output io.Writer) error {
+	if publish && s.amqpClient == nil {
+		return errNoPublisher
+	}
+
+	if extensions == nil {
+		extensions = s.scanExtensions
+	}
+
+	writer := newNDJSONWriter(output)
+	limiter := concurrent.NewLimiter(s.batchConcurrency)
+
+	err := s.storage.Walk(ctx, directory, func(item absto.Item) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		if item.IsDir() || s.isCacheItem(item) || (len(extensions) != 0 && !slices.Contains(extensions, item.Extension)) {
+			return nil
+		}
+
+		limiter.Go(func() {
+			s.scanItem(ctx, writer, item, publish, opts)
+		})
+
+		return nil
+	})
+
+	limiter.Wait()
+
+	if err != nil {
+		return fmt.Errorf("walk: %w", err)
+	}
+
+	return nil
+}
+
+func (s Service) scanItem(ctx context.Context, writer *ndjsonWriter, item absto.Item, publish bool, opts options) {
+	response := itemResponse{Pathname: item.Pathname}
+
+	exif, err := s.getItem(ctx, item, opts)
+	if err == nil && publish {
+		err = s.publish(ctx, item, exif)
+	}
+
+	if err != nil {
+		slog.LogAttrs(ctx, slog.LevelError, "scan item", slog.String("item", item.Pathname), slog.Any("error", err))
+		status, code := errorCategory(err)
+		response.Error, response.Code = errorMessage(err, status, code), code
+		s.increaseMetric(ctx, "scan", "exif", response.Code)
+	} else {
+		if !publish {
+			response.Exif = &exif
+		}
+
+		s.increaseMetric(ctx, "scan", "exif", "success")
+	}
+
+	if err := writer.Write(response); err != nil {
+		slog.LogAttrs(ctx, slog.LevelError, "write scan item", slog.String("item", item.Pathname), slog.Any("error", err))
+	}
+}
+
+func normalizeExtensions(values []string) []string {
+	output := splitParams(values)
+
+	for index, extension := range output {
+		extension = strings.ToLower(extension)
+
+		if !strings.HasPrefix(extension, ".") {
+			extension = "." + extension
+		}
+
+		output[index] = extension
+	}
+
+	return output
+}
@@ -0,0 +1,174 @@
+package exas
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/ViBiOh/absto/pkg/filesystem"
+)
+
+func newScanService(t *testing.T) Service {
+	t.Helper()
+
+	directory := t.TempDir()
+
+	for _, name := range []string{"a.jpg", "b.txt", "sub/c.JPG"} {
+		path := filepath.Join(directory, name)
+
+		if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := os.WriteFile(path, []byte(name), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	storage, err := filesystem.New(directory)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return Service{
+		storage:          storage,
+		exiftool:         newFakeExiftool(t, 0),
+		batchConcurrency: 2,
+	}
+}
+
+func TestScan(t *testing.T) {
+	t.Parallel()
+
+	service := newScanService(t)
+
+	type args struct {
+		directory  string
+		extensions []string
+		publish    bool
+	}
+
+	cases := map[string]struct {
+		args    args
+		want    []string
+		wantErr error
+	}{
+		"all": {
+			args{
+				directory:  "/",
+				extensions: []string{},
+			},
+			[]string{"/a.jpg", "/b.txt", "/sub/c.JPG"},
+			nil,
+		},
+		"extensions": {
+			args{
+				directory:  "/",
+				extensions: normalizeExtensions([]string{"jpg"}),
+			},
+			[]string{"/a.jpg", "/sub/c.JPG"},
+			nil,
+		},
+		"subdirectory": {
+			args{
+				directory:  "/sub/",
+				extensions: []string{},
+			},
+			[]string{"/sub/c.JPG"},
+			nil,
+		},
+		"no publisher": {
+			args{
+				directory: "/",
+				publish:   true,
+			},
+			nil,
+			errNoPublisher,
+		},
+	}
+
+	for intention, tc := range cases {
+		t.Run(intention, func(t *testing.T) {
+			t.Parallel()
+
+			var output bytes.Buffer
+
+			gotErr := service.scan(context.Background(), tc.args.directory, tc.args.extensions, tc.args.publish, options{}, &output)
+
+			var got []string
+			for line := range strings.Lines(output.String()) {
+				var response itemResponse
+				if err := json.Unmarshal([]byte(line), &response); err != nil {
+					t.Fatal(err)
+				}
+
+				got = append(got, response.Pathname)
+			}
+
+			slices.Sort(got)
+
+			if !errors.Is(gotErr, tc.wantErr) {
+				t.Errorf("scan() error = %v, want %v", gotErr, tc.wantErr)
+			} else if !slices.Equal(got, tc.want) {
+				t.Errorf("scan() = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestHandleScan(t *testing.T) {
+	t.Parallel()
+
+	service := newScanService(t)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /scan/{dir...}", service.HandleScan)
+
+	type args struct {
+		target string
+	}
+
+	cases := map[string]struct {
+		args       args
+		want       string
+		wantStatus int
+	}{
+		"scan": {
+			args{
+				target: "/scan/sub/?extension=jpg",
+			},
+			`"pathname":"/sub/c.JPG"`,
+			http.StatusOK,
+		},
+		"no publisher": {
+			args{
+				target: "/scan/?publish",
+			},
+			`"code":"no_publisher"`,
+			http.StatusBadRequest,
+		},
+	}
+
+	for intention, tc := range cases {
+		t.Run(intention, func(t *testing.T) {
+			t.Parallel()
+
+			writer := httptest.NewRecorder()
+			mux.ServeHTTP(writer, httptest.NewRequest(http.MethodGet, tc.args.target, nil))
+
+			if writer.Code != tc.wantStatus {
+				t.Errorf("HandleScan() status = %d, want %d", writer.Code, tc.wantStatus)
+			} else if !strings.Contains(writer.Body.String(), tc.want) {
+				t.Errorf("HandleScan() = `%s`, want `%s`", writer.Body.String(), tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,55 @@
+package exas
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/ViBiOh/exas/pkg/geocode"
+	"github.com/ViBiOh/httputils/v4/pkg/httpjson"
+)
+
+const (
+	defaultSearchLimit = 5
+	maxSearchLimit     = 50
+)
+
+var errInvalidQuery = errors.New("invalid query")
+
+func (s Service) HandleSearch(w http.ResponseWriter, r *http.Request) {
+	if !s.geocode.Searchable() {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	ctx := r.Context()
+
+	query := strings.TrimSpace(r.URL.Query().Get("q"))
+	if len(query) == 0 {
+		s.httpError(ctx, w, "search", fmt.Errorf("`q` query param is required: %w", errInvalidQuery))
+		return
+	}
+
+	limit := defaultSearchLimit
+
+	if rawLimit := r.URL.Query().Get("limit"); len(rawLimit) != 0 {
+		var err error
+
+		limit, err = strconv.Atoi(rawLimit)
+		if err != nil || limit < 1 || limit > maxSearchLimit {
+			s.httpError(ctx, w, "search", fmt.Errorf("`limit` query param must be between 1 and %d: %w", maxSearchLimit, errInvalidQuery))
+			return
+		}
+	}
+
+	candidates, err := s.geocode.Search(ctx, query, limit, geocode.ParseLanguage(r.Header.Get("Accept-Language")))
+	if err != nil {
+		s.httpError(ctx, w, "search", err)
+		return
+	}
+
+	httpjson.Write(ctx, w, http.StatusOK, candidates)
+	s.increaseMetric(ctx, "http", "search", "success")
+}
@@ -0,0 +1,86 @@
+package exas
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestHandleSearch(t *testing.T) {
+	t.Parallel()
+
+	type args struct {
+		target   string
+		failures int64
+		status   int
+		rate     float64
+	}
+
+	cases := map[string]struct {
+		args       args
+		want       string
+		wantStatus int
+	}{
+		"success": {
+			args{
+				target: "/geocode/search?q=Lisbon",
+			},
+			"[]",
+			http.StatusOK,
+		},
+		"missing query": {
+			args{
+				target: "/geocode/search",
+			},
+			`"code":"invalid_query"`,
+			http.StatusBadRequest,
+		},
+		"invalid limit": {
+			args{
+				target: "/geocode/search?q=Lisbon&limit=100",
+			},
+			`"code":"invalid_query"`,
+			http.StatusBadRequest,
+		},
+		"provider": {
+			args{
+				target:   "/geocode/search?q=Lisbon",
+				failures: 1,
+				status:   http.StatusServiceUnavailable,
+			},
+			`{"code":"geocode_error","message":"` + providerErrorMessage + `"}`,
+			http.StatusBadGateway,
+		},
+		"limited": {
+			args{
+				target: "/geocode/search?q=Lisbon",
+				rate:   1e-6,
+			},
+			`"code":"timeout"`,
+			http.StatusGatewayTimeout,
+		},
+	}
+
+	for intention, tc := range cases {
+		t.Run(intention, func(t *testing.T) {
+			t.Parallel()
+
+			service, _ := newGeocodeService(t, tc.args.failures, tc.args.status, tc.args.rate)
+
+			if tc.args.rate > 0 {
+				// the only token of the rate limit is taken
+				service.HandleSearch(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, tc.args.target, nil))
+			}
+
+			writer := httptest.NewRecorder()
+			service.HandleSearch(writer, httptest.NewRequest(http.MethodGet, tc.args.target, nil))
+
+			if writer.Code != tc.wantStatus {
+				t.Errorf("HandleSearch() status = %d, want %d", writer.Code, tc.wantStatus)
+			} else if body := writer.Body.String(); !strings.Contains(body, tc.want) || strings.Contains(body, "127.0.0.1") {
+				t.Errorf("HandleSearch() = `%s`, want `%s`", body, tc.want)
+			}
+		})
+	}
+}
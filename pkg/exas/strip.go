@@ -0,0 +1,240 @@
+package exas
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/ViBiOh/httputils/v4/pkg/httperror"
+	"github.com/ViBiOh/httputils/v4/pkg/telemetry"
+)
+
+const removedTagsHeader = "X-Exas-Removed-Tags"
+
+var (
+	stripTagRegex = regexp.MustCompile(`^[\w*][\w*-]*(?::[\w*][\w*-]*)*$`)
+
+	gpsStripArgs = []string{
+		"-gps:all=",
+		"-GPS*=",
+		"-XMP:Location*=",
+		"-XMP:City=",
+		"-XMP:State=",
+		"-XMP:Country=",
+		"-IPTC:City=",
+		"-IPTC:Sub-location=",
+		"-IPTC:Province-State=",
+		"-IPTC:Country-PrimaryLocationName=",
+	}
+
+	stripProfiles = map[string][]string{
+		// Orientation and color profile are kept for the image to render the same
+		"all": {"-all=", "-tagsFromFile", "@", "-Orientation", "-ICC_Profile"},
+		"gps": gpsStripArgs,
+		"personal": append(slices.Clone(gpsStripArgs),
+			"-*SerialNumber=",
+			"-OwnerName=",
+			"-CameraOwnerName=",
+			"-Artist=",
+			"-XPAuthor=",
+			"-Creator*=",
+			"-By-line*=",
+			"-Copyright*=",
+			"-Rights=",
+			"-HostComputer=",
+		),
+	}
+)
+
+func (s Service) HandleStrip(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	defer closeWithLog(ctx, r.Body, "HandleStrip", "input")
+
+	args, err := stripArgs(r.URL.Query())
+	if err != nil {
+		s.httpError(ctx, w, "strip", err)
+		return
+	}
+
+	name, err := writeTemp(s.limitPayload(w, r), "")
+	if err != nil {
+		s.httpError(ctx, w, "strip", fmt.Errorf("write input: %w", err))
+		return
+	}
+	defer removeWithLog(ctx, name)
+
+	output, removed, err := s.strip(ctx, name, args)
+	if err != nil {
+		s.httpError(ctx, w, "strip", err)
+		return
+	}
+	defer removeWithLog(ctx, output)
+
+	file, err := os.Open(output)
+	if err != nil {
+		s.httpError(ctx, w, "strip", fmt.Errorf("open output: %w", err))
+		return
+	}
+	defer closeWithLog(ctx, file, "HandleStrip", "output")
+
+	info, err := file.Stat()
+	if err != nil {
+		s.httpError(ctx, w, "strip", fmt.Errorf("stat output: %w", err))
+		return
+	}
+
+	header := make([]byte, 512)
+	read, _ := io.ReadFull(file, header)
+	header = header[:read]
+
+	w.Header().Set("Content-Type", http.DetectContentType(header))
+	w.Header().Set("Content-Length", strconv.FormatInt(info.Size(), 10))
+	w.Header().Set(removedTagsHeader, strings.Join(removed, ","))
+	w.WriteHeader(http.StatusOK)
+
+	if _, err = io.Copy(w, io.MultiReader(bytes.NewReader(header), file)); err != nil {
+		s.increaseMetric(ctx, "http", "strip", "error")
+		httperror.Log(ctx, err, http.StatusInternalServerError, "write stripped file")
+		return
+	}
+
+	s.increaseMetric(ctx, "http", "strip", "success")
+}
+
+// strip writes the sanitized copy of `input` beside it and returns its name with the removed tag names.
+// The payload has no name, both files are named with the extension of the detected type for exiftool to pick the format writer.
+func (s Service) strip(ctx context.Context, input string, args []string) (output string, removed []string, err error) {
+	ctx, end := telemetry.StartSpan(ctx, s.tracer, "strip")
+	defer end(&err)
+
+	before, err := s.readTags(ctx, input)
+	if err != nil {
+		return "", nil, fmt.Errorf("read tags: %w", err)
+	}
+
+	extension := fileExtension(before)
+	output = input + ".strip" + extension
+
+	if len(extension) != 0 {
+		typed := input + extension
+		if err = os.Link(input, typed); err != nil {
+			return "", nil, fmt.Errorf("link input: %w", err)
+		}
+		defer removeWithLog(ctx, typed)
+
+		input = typed
+	}
+
+	buffer := bufferPool.Get().(*bytes.Buffer)
+	defer bufferPool.Put(buffer)
+
+	buffer.Reset()
+
+	if err = s.exiftool.run(ctx, buffer, append(args, "-o", output, input)...); err != nil {
+		removeWithLog(ctx, output)
+
+		return "", nil, fmt.Errorf("strip tags: %w", err)
+	}
+
+	after, err := s.readTags(ctx, output)
+	if err != nil {
+		removeWithLog(ctx, output)
+
+		return "", nil, fmt.Errorf("read stripped tags: %w", err)
+	}
+
+	for tag := range before {
+		if _, ok := after[tag]; !ok {
+			removed = append(removed, tag)
+		}
+	}
+
+	slices.Sort(removed)
+
+	return output, removed, nil
+}
+
+// fileExtension returns the extension of the file type detected by exiftool, empty if unknown
+func fileExtension(tags map[string]any) string {
+	extension, _ := tags["FileTypeExtension"].(string)
+	if len(extension) == 0 {
+		return ""
+	}
+
+	return tempExtension("." + extension)
+}
+
+// isTagPattern reports if the tag is a name, optionally grouped or with wildcards, that exiftool won't mistake for an option
+func isTagPattern(tag string) bool {
+	return stripTagRegex.MatchString(tag) && !isExiftoolOption(tag)
+}
+
+// isStripTagName reports if the tag pattern can be kept or removed, file pseudo-tags renaming or moving the output
+func isStripTagName(tag string) bool {
+	return isTagPattern(tag) && !isFileTag(tag)
+}
+
+func stripArgs(params url.Values) ([]string, error) {
+	if keep := splitParams(params["keep"]); len(keep) != 0 {
+		args := []string{"-all=", "-tagsFromFile", "@"}
+
+		for _, tag := range keep {
+			if !isStripTagName(tag) {
+				return nil, fmt.Errorf("name `%s`: %w", tag, errInvalidTag)
+			}
+
+			args = append(args, "-"+tag)
+		}
+
+		return args, nil
+	}
+
+	if remove := splitParams(params["remove"]); len(remove) != 0 {
+		var args []string
+
+		for _, tag := range remove {
+			if !isStripTagName(tag) {
+				return nil, fmt.Errorf("name `%s`: %w", tag, errInvalidTag)
+			}
+
+			args = append(args, "-"+tag+"=")
+		}
+
+		return args, nil
+	}
+
+	profile := "all"
+	if values := params["profile"]; len(values) != 0 && len(values[0]) != 0 {
+		profile = values[0]
+	}
+
+	args, ok := stripProfiles[profile]
+	if !ok {
+		return nil, fmt.Errorf("unknown profile `%s`: %w", profile, errInvalidTag)
+	}
+
+	return slices.Clone(args), nil
+}
+
+func splitParams(values []string) []string {
+	var output []string
+
+	for _, value := range values {
+		for part := range strings.SplitSeq(value, ",") {
+			if part = strings.TrimSpace(part); len(part) != 0 {
+				output = append(output, part)
+			}
+		}
+	}
+
+	return output
+}
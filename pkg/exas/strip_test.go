@@ -0,0 +1,194 @@
+package exas
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestStripArgs(t *testing.T) {
+	t.Parallel()
+
+	type args struct {
+		params url.Values
+	}
+
+	cases := map[string]struct {
+		args    args
+		want    []string
+		wantErr error
+	}{
+		"default": {
+			args{
+				params: url.Values{},
+			},
+			[]string{"-all=", "-tagsFromFile", "@", "-Orientation", "-ICC_Profile"},
+			nil,
+		},
+		"profile": {
+			args{
+				params: url.Values{"profile": {"gps"}},
+			},
+			gpsStripArgs,
+			nil,
+		},
+		"unknown profile": {
+			args{
+				params: url.Values{"profile": {"everything"}},
+			},
+			nil,
+			errInvalidTag,
+		},
+		"keep": {
+			args{
+				params: url.Values{"keep": {"Orientation, Rating", "XMP:*"}, "profile": {"gps"}},
+			},
+			[]string{"-all=", "-tagsFromFile", "@", "-Orientation", "-Rating", "-XMP:*"},
+			nil,
+		},
+		"remove": {
+			args{
+				params: url.Values{"remove": {"Artist,SerialNumber"}},
+			},
+			[]string{"-Artist=", "-SerialNumber="},
+			nil,
+		},
+		"invalid keep": {
+			args{
+				params: url.Values{"keep": {"Orientation=1"}},
+			},
+			nil,
+			errInvalidTag,
+		},
+		"keep option": {
+			args{
+				params: url.Values{"keep": {"Orientation,execute"}},
+			},
+			nil,
+			errInvalidTag,
+		},
+		"remove option": {
+			args{
+				params: url.Values{"remove": {"srcfile"}},
+			},
+			nil,
+			errInvalidTag,
+		},
+		"keep file name": {
+			args{
+				params: url.Values{"keep": {"Orientation,FileName"}},
+			},
+			nil,
+			errInvalidTag,
+		},
+		"remove directory": {
+			args{
+				params: url.Values{"remove": {"Directory"}},
+			},
+			nil,
+			errInvalidTag,
+		},
+		"remove file group": {
+			args{
+				params: url.Values{"remove": {"System:all"}},
+			},
+			nil,
+			errInvalidTag,
+		},
+		"invalid remove": {
+			args{
+				params: url.Values{"remove": {"-o"}},
+			},
+			nil,
+			errInvalidTag,
+		},
+	}
+
+	for intention, tc := range cases {
+		t.Run(intention, func(t *testing.T) {
+			t.Parallel()
+
+			got, gotErr := stripArgs(tc.args.params)
+
+			if !errors.Is(gotErr, tc.wantErr) {
+				t.Errorf("stripArgs() error = %v, want %v", gotErr, tc.wantErr)
+			} else if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("stripArgs() = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestFileExtension(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]struct {
+		tags map[string]any
+		want string
+	}{
+		"unknown": {
+			map[string]any{},
+			"",
+		},
+		"jpeg": {
+			map[string]any{"FileTypeExtension": "JPG"},
+			".jpg",
+		},
+		"invalid": {
+			map[string]any{"FileTypeExtension": "jpg/../x"},
+			"",
+		},
+	}
+
+	for intention, testCase := range cases {
+		t.Run(intention, func(t *testing.T) {
+			t.Parallel()
+
+			if got := fileExtension(testCase.tags); got != testCase.want {
+				t.Errorf("fileExtension() = `%s`, want `%s`", got, testCase.want)
+			}
+		})
+	}
+}
+
+func TestHandleStrip(t *testing.T) {
+	t.Parallel()
+
+	service := Service{exiftool: newLocalExiftool(t)}
+
+	name := filepath.Join(t.TempDir(), "image.jpg")
+	writeJPEG(t, name)
+
+	if err := service.exiftool.run(context.Background(), &bytes.Buffer{}, "-Artist=exas", "-overwrite_original", name); err != nil {
+		t.Fatalf("write Artist: %v", err)
+	}
+
+	payload, err := os.ReadFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	writer := httptest.NewRecorder()
+	service.HandleStrip(writer, httptest.NewRequest(http.MethodPost, "/strip?remove=Artist", bytes.NewReader(payload)))
+
+	if got := writer.Code; got != http.StatusOK {
+		t.Fatalf("HandleStrip() = %d, want %d: %s", got, http.StatusOK, writer.Body.String())
+	}
+
+	if got := writer.Header().Get(removedTagsHeader); !slices.Contains(strings.Split(got, ","), "Artist") {
+		t.Errorf("HandleStrip() %s = `%s`, want `Artist`", removedTagsHeader, got)
+	}
+
+	if got := writer.Header().Get("Content-Type"); got != "image/jpeg" {
+		t.Errorf("HandleStrip() Content-Type = `%s`, want `image/jpeg`", got)
+	}
+}
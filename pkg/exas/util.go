@@ -2,12 +2,73 @@ package exas
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"io"
 	"log/slog"
+	"net/http"
+	"os"
+	"regexp"
+	"strings"
 )
 
+var extensionRegex = regexp.MustCompile(`^\.[a-z0-9]{1,10}$`)
+
 func closeWithLog(ctx context.Context, closer io.Closer, fn, item string) {
 	if err := closer.Close(); err != nil {
 		slog.LogAttrs(ctx, slog.LevelError, "close", slog.String("fn", fn), slog.String("item", item), slog.Any("error", err))
 	}
 }
+
+// writeTemp copies the input to a temporary file named with the given extension, exiftool picking the format writer from it
+func writeTemp(input io.Reader, extension string) (string, error) {
+	file, err := os.CreateTemp("", "exas-*"+tempExtension(extension))
+	if err != nil {
+		return "", fmt.Errorf("create: %w", err)
+	}
+
+	if _, err = io.Copy(file, input); err != nil {
+		if _, ok := errors.AsType[*http.MaxBytesError](err); ok {
+			err = errors.Join(err, errTooLarge)
+		}
+
+		err = fmt.Errorf("copy: %w", err)
+	}
+
+	if closeErr := file.Close(); closeErr != nil {
+		err = errors.Join(err, fmt.Errorf("close: %w", closeErr))
+	}
+
+	if err != nil {
+		_ = os.Remove(file.Name())
+
+		return "", err
+	}
+
+	return file.Name(), nil
+}
+
+// tempExtension returns the lowercased extension if it's safe to put in a temporary file name, empty otherwise
+func tempExtension(extension string) string {
+	extension = strings.ToLower(extension)
+	if !extensionRegex.MatchString(extension) {
+		return ""
+	}
+
+	return extension
+}
+
+// limitPayload caps the request body to the configured size, reading beyond fails with an errTooLarge once written to disk
+func (s Service) limitPayload(w http.ResponseWriter, r *http.Request) io.Reader {
+	if s.payloadMaxSize == 0 {
+		return r.Body
+	}
+
+	return http.MaxBytesReader(w, r.Body, s.payloadMaxSize)
+}
+
+func removeWithLog(ctx context.Context, name string) {
+	if err := os.Remove(name); err != nil && !errors.Is(err, os.ErrNotExist) {
+		slog.LogAttrs(ctx, slog.LevelError, "remove", slog.String("item", name), slog.Any("error", err))
+	}
+}
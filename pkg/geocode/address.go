@@ -0,0 +1,204 @@
+package geocode
+
+import (
+	"fmt"
+	"maps"
+	"math"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/ViBiOh/exas/pkg/model"
+)
+
+const (
+	minZoom = 3
+	maxZoom = 18
+)
+
+var (
+	// coordinateTags are the tags of raw data holding coordinates, matched without their group
+	coordinateTags = map[string]struct{}{
+		"GPSLatitude":      {},
+		"GPSLongitude":     {},
+		"GPSPosition":      {},
+		"GPSCoordinates":   {},
+		"GPSDestLatitude":  {},
+		"GPSDestLongitude": {},
+	}
+
+	decimalRegex = regexp.MustCompile(`[-+]?\d+\.\d+`)
+)
+
+// zooms are the Nominatim zoom levels, https://nominatim.org/release-docs/latest/api/Reverse/#result-restriction
+var zooms = map[string]int{
+	"country":  minZoom,
+	"state":    5,
+	"city":     10,
+	"suburb":   14,
+	"street":   17,
+	"building": maxZoom,
+}
+
+// keyZooms are the zoom levels from which an address key is kept, other keys (e.g. `house_number`, `amenity`) require the max zoom
+var keyZooms = map[string]int{
+	"country":        3,
+	"country_code":   3,
+	"state":          5,
+	"state_district": 5,
+	"region":         5,
+	"province":       5,
+	"ISO3166-2-lvl4": 5,
+	"ISO3166-2-lvl6": 5,
+	"county":         10,
+	"municipality":   10,
+	"city":           10,
+	"town":           10,
+	"village":        10,
+	"postcode":       10,
+	"city_district":  14,
+	"borough":        14,
+	"suburb":         14,
+	"quarter":        14,
+	"neighbourhood":  14,
+	"hamlet":         14,
+	"road":           17,
+}
+
+func parseZoom(value string) (int, error) {
+	if len(value) == 0 {
+		return maxZoom, nil
+	}
+
+	if zoom, ok := zooms[value]; ok {
+		return zoom, nil
+	}
+
+	zoom, err := strconv.Atoi(value)
+	if err != nil || zoom < minZoom || zoom > maxZoom {
+		return 0, fmt.Errorf("unknown zoom `%s`", value)
+	}
+
+	return zoom, nil
+}
+
+// addressFilter restricts the address keys, by zoom level then by the included or excluded keys. A zero zoom doesn't restrict.
+type addressFilter struct {
+	include []string
+	exclude []string
+	zoom    int
+}
+
+func (f addressFilter) apply(address map[string]string) map[string]string {
+	if len(address) == 0 || (!f.zoomed() && len(f.include) == 0 && len(f.exclude) == 0) {
+		return address
+	}
+
+	output := make(map[string]string, len(address))
+
+	for key, value := range address {
+		if f.keep(key) {
+			output[key] = value
+		}
+	}
+
+	return output
+}
+
+func (f addressFilter) zoomed() bool {
+	return f.zoom != 0 && f.zoom < maxZoom
+}
+
+func (f addressFilter) keep(key string) bool {
+	if f.zoomed() {
+		keyZoom, ok := keyZooms[key]
+		if !ok || keyZoom > f.zoom {
+			return false
+		}
+	}
+
+	if len(f.include) != 0 && !slices.Contains(f.include, key) {
+		return false
+	}
+
+	return !slices.Contains(f.exclude, key)
+}
+
+func coordinatesFactor(decimals int) float64 {
+	if decimals < 0 {
+		return 0
+	}
+
+	return math.Pow10(decimals)
+}
+
+// Coarsen rounds the coordinates to the configured number of decimals, if any.
+func (s Service) Coarsen(geocode model.Geocode) model.Geocode {
+	if s.coordinatesFactor == 0 {
+		return geocode
+	}
+
+	geocode.Latitude = s.round(geocode.Latitude)
+	geocode.Longitude = s.round(geocode.Longitude)
+
+	return geocode
+}
+
+// CoarsenExif rounds the coordinates of the geocode, of the GPS tags of the raw data and of the video track, if configured.
+// Data is copied before being rounded, as it may be shared with a cached Exif.
+func (s Service) CoarsenExif(exif model.Exif) model.Exif {
+	if s.coordinatesFactor == 0 {
+		return exif
+	}
+
+	exif.Geocode = s.Coarsen(exif.Geocode)
+
+	var data map[string]any
+
+	for key, value := range exif.Data {
+		if _, ok := coordinateTags[key[strings.LastIndexByte(key, ':')+1:]]; !ok {
+			continue
+		}
+
+		if data == nil {
+			data = maps.Clone(exif.Data)
+		}
+
+		switch typed := value.(type) {
+		case float64:
+			data[key] = s.round(typed)
+		case string:
+			data[key] = decimalRegex.ReplaceAllStringFunc(typed, s.roundString)
+		}
+	}
+
+	if data != nil {
+		exif.Data = data
+	}
+
+	for index, bound := range exif.Metadata.Video.Track.Bounds {
+		exif.Metadata.Video.Track.Bounds[index] = s.round(bound)
+	}
+
+	return exif
+}
+
+func (s Service) round(value float64) float64 {
+	return math.Round(value*s.coordinatesFactor) / s.coordinatesFactor
+}
+
+// roundString keeps the explicit sign of the exiftool `-coordFormat`
+func (s Service) roundString(value string) string {
+	number, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return value
+	}
+
+	output := strconv.FormatFloat(s.round(number), 'f', -1, 64)
+	if strings.HasPrefix(value, "+") {
+		output = "+" + output
+	}
+
+	return output
+}
@@ -0,0 +1,175 @@
+package geocode
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/ViBiOh/exas/pkg/model"
+)
+
+func TestAddressFilterApply(t *testing.T) {
+	t.Parallel()
+
+	address := map[string]string{
+		"house_number": "5",
+		"road":         "Avenue Anatole France",
+		"suburb":       "Gros-Caillou",
+		"city":         "Paris",
+		"postcode":     "75007",
+		"state":        "Île-de-France",
+		"country":      "France",
+		"country_code": "fr",
+	}
+
+	type args struct {
+		filter addressFilter
+	}
+
+	cases := map[string]struct {
+		args args
+		want map[string]string
+	}{
+		"no filter": {
+			args{
+				filter: addressFilter{},
+			},
+			address,
+		},
+		"city": {
+			args{
+				filter: addressFilter{zoom: zooms["city"]},
+			},
+			map[string]string{"city": "Paris", "postcode": "75007", "state": "Île-de-France", "country": "France", "country_code": "fr"},
+		},
+		"include": {
+			args{
+				filter: addressFilter{include: []string{"city", "country", "road"}, zoom: zooms["suburb"]},
+			},
+			map[string]string{"city": "Paris", "country": "France"},
+		},
+		"exclude": {
+			args{
+				filter: addressFilter{exclude: []string{"house_number", "road", "suburb", "postcode"}},
+			},
+			map[string]string{"city": "Paris", "state": "Île-de-France", "country": "France", "country_code": "fr"},
+		},
+	}
+
+	for intention, tc := range cases {
+		t.Run(intention, func(t *testing.T) {
+			t.Parallel()
+
+			if got := tc.args.filter.apply(address); !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("apply() = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestCoarsen(t *testing.T) {
+	t.Parallel()
+
+	type args struct {
+		decimals int
+	}
+
+	cases := map[string]struct {
+		args args
+		want model.Geocode
+	}{
+		"disabled": {
+			args{
+				decimals: -1,
+			},
+			model.Geocode{Latitude: 48.858370, Longitude: -2.294481},
+		},
+		"two decimals": {
+			args{
+				decimals: 2,
+			},
+			model.Geocode{Latitude: 48.86, Longitude: -2.29},
+		},
+	}
+
+	for intention, tc := range cases {
+		t.Run(intention, func(t *testing.T) {
+			t.Parallel()
+
+			service := Service{coordinatesFactor: coordinatesFactor(tc.args.decimals)}
+
+			if got := service.Coarsen(model.Geocode{Latitude: 48.858370, Longitude: -2.294481}); !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("Coarsen() = %+v, want %+v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestCoarsenExif(t *testing.T) {
+	t.Parallel()
+
+	exif := model.Exif{
+		Geocode: model.Geocode{Latitude: 48.858370, Longitude: -2.294481},
+		Data: map[string]any{
+			"Make":                  "Canon",
+			"GPSLatitude":           "+48.85837000",
+			"GPS:GPSLongitude":      -2.294481,
+			"Composite:GPSPosition": "+48.85837000, -2.29448100",
+		},
+		Metadata: model.Metadata{
+			Video: model.Video{
+				Track: model.Track{Bounds: [4]float64{48.858370, -2.294481, 48.861234, -2.290123}, Points: 2},
+			},
+		},
+	}
+
+	type args struct {
+		decimals int
+	}
+
+	cases := map[string]struct {
+		args args
+		want model.Exif
+	}{
+		"disabled": {
+			args{
+				decimals: -1,
+			},
+			exif,
+		},
+		"two decimals": {
+			args{
+				decimals: 2,
+			},
+			model.Exif{
+				Geocode: model.Geocode{Latitude: 48.86, Longitude: -2.29},
+				Data: map[string]any{
+					"Make":                  "Canon",
+					"GPSLatitude":           "+48.86",
+					"GPS:GPSLongitude":      -2.29,
+					"Composite:GPSPosition": "+48.86, -2.29",
+				},
+				Metadata: model.Metadata{
+					Video: model.Video{
+						Track: model.Track{Bounds: [4]float64{48.86, -2.29, 48.86, -2.29}, Points: 2},
+					},
+				},
+			},
+		},
+	}
+
+	for intention, tc := range cases {
+		t.Run(intention, func(t *testing.T) {
+			t.Parallel()
+
+			service := Service{coordinatesFactor: coordinatesFactor(tc.args.decimals)}
+
+			if got := service.CoarsenExif(exif); !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("CoarsenExif() = %+v, want %+v", got, tc.want)
+			}
+
+			if exif.Data["GPSLatitude"] != "+48.85837000" {
+				t.Errorf("CoarsenExif() modified the given data: %+v", exif.Data)
+			}
+		})
+	}
+}
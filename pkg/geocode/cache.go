@@ -0,0 +1,132 @@
+package geocode
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+
+	absto "github.com/ViBiOh/absto/pkg/model"
+	"github.com/ViBiOh/exas/pkg/lru"
+)
+
+// cache holds addresses of coordinates rounded to a given precision, so close pictures share the same address
+type cache struct {
+	memory    *lru.Cache[string, map[string]string]
+	storage   absto.Storage
+	mkdir     *sync.Once
+	directory string
+	precision int
+}
+
+func newCache(config *Config, storage absto.Storage) (*cache, error) {
+	if config.GeocodeCacheSize == 0 {
+		return nil, nil
+	}
+
+	output := &cache{
+		memory:    lru.New[string, map[string]string](int(config.GeocodeCacheSize)),
+		precision: int(config.GeocodeCachePrecision),
+	}
+
+	if len(config.GeocodeCacheDirectory) != 0 {
+		if storage == nil || !storage.Enabled() {
+			return nil, fmt.Errorf("persistence in `%s` requires a storage", config.GeocodeCacheDirectory)
+		}
+
+		output.storage = storage
+		output.directory = absto.Dirname(config.GeocodeCacheDirectory)
+		output.mkdir = &sync.Once{}
+	}
+
+	return output, nil
+}
+
+func (c *cache) key(latitude, longitude float64, language string) string {
+	key := strconv.FormatFloat(latitude, 'f', c.precision, 64) + "_" + strconv.FormatFloat(longitude, 'f', c.precision, 64)
+
+	if len(language) != 0 {
+		key += "_" + strings.ReplaceAll(language, ",", "_")
+	}
+
+	return key
+}
+
+func (c *cache) filename(key string) string {
+	return path.Join(c.directory, key+".json")
+}
+
+func (c *cache) get(ctx context.Context, key string) (map[string]string, bool) {
+	if address, ok := c.memory.Get(key); ok {
+		return address, true
+	}
+
+	if c.storage == nil {
+		return nil, false
+	}
+
+	reader, err := c.storage.ReadFrom(ctx, c.filename(key))
+	if err != nil {
+		if !absto.IsNotExist(err) {
+			slog.LogAttrs(ctx, slog.LevelError, "read geocode cache", slog.String("key", key), slog.Any("error", err))
+		}
+
+		return nil, false
+	}
+
+	defer func() {
+		if closeErr := reader.Close(); closeErr != nil {
+			slog.LogAttrs(ctx, slog.LevelError, "close geocode cache", slog.String("key", key), slog.Any("error", closeErr))
+		}
+	}()
+
+	var address map[string]string
+	if err = json.NewDecoder(reader).Decode(&address); err != nil {
+		slog.LogAttrs(ctx, slog.LevelError, "decode geocode cache", slog.String("key", key), slog.Any("error", err))
+		return nil, false
+	}
+
+	c.memory.Set(key, address)
+
+	return address, true
+}
+
+func (c *cache) set(ctx context.Context, key string, address map[string]string) {
+	c.memory.Set(key, address)
+
+	if c.storage == nil {
+		return
+	}
+
+	if err := c.persist(ctx, key, address); err != nil {
+		slog.LogAttrs(ctx, slog.LevelError, "persist geocode cache", slog.String("key", key), slog.Any("error", err))
+	}
+}
+
+func (c *cache) persist(ctx context.Context, key string, address map[string]string) error {
+	var err error
+
+	c.mkdir.Do(func() {
+		err = c.storage.Mkdir(ctx, c.directory, absto.DirectoryPerm)
+	})
+
+	if err != nil {
+		return fmt.Errorf("mkdir: %w", err)
+	}
+
+	payload, err := json.Marshal(address)
+	if err != nil {
+		return fmt.Errorf("encode: %w", err)
+	}
+
+	if err = c.storage.WriteTo(ctx, c.filename(key), bytes.NewReader(payload), absto.WriteOpts{Size: int64(len(payload))}); err != nil {
+		return fmt.Errorf("write: %w", err)
+	}
+
+	return nil
+}
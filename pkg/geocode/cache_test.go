@@ -0,0 +1,80 @@
+package geocode
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+
+	"github.com/ViBiOh/exas/pkg/model"
+)
+
+type stubProvider struct {
+	calls atomic.Int32
+}
+
+func (s *stubProvider) Reverse(_ context.Context, _, _ float64, _ string) (map[string]string, error) {
+	s.calls.Add(1)
+
+	return map[string]string{"city": "Paris"}, nil
+}
+
+func (s *stubProvider) Search(_ context.Context, _ string, _ int, _ string) ([]model.Geocode, error) {
+	s.calls.Add(1)
+
+	return nil, nil
+}
+
+func TestGetReverseGeocodeCache(t *testing.T) {
+	t.Parallel()
+
+	type args struct {
+		coordinates [][2]float64
+	}
+
+	cases := map[string]struct {
+		args args
+		want int32
+	}{
+		"same bucket": {
+			args{
+				coordinates: [][2]float64{{48.85837, 2.29418}, {48.85842, 2.29431}, {48.8584, 2.2942}},
+			},
+			1,
+		},
+		"different buckets": {
+			args{
+				coordinates: [][2]float64{{48.85837, 2.29448}, {48.86, 2.29448}},
+			},
+			2,
+		},
+	}
+
+	for intention, tc := range cases {
+		t.Run(intention, func(t *testing.T) {
+			t.Parallel()
+
+			cache, err := newCache(&Config{GeocodeCacheSize: 10, GeocodeCachePrecision: 3}, nil)
+			if err != nil {
+				t.Fatalf("newCache: %s", err)
+			}
+
+			provider := &stubProvider{}
+			service := Service{provider: provider, cache: cache}
+
+			for _, coordinates := range tc.args.coordinates {
+				got, err := service.getReverseGeocode(context.Background(), model.Geocode{Latitude: coordinates[0], Longitude: coordinates[1]}, "")
+				if err != nil {
+					t.Fatalf("getReverseGeocode: %s", err)
+				}
+
+				if got.Address["city"] != "Paris" || got.Latitude != coordinates[0] {
+					t.Errorf("getReverseGeocode() = %+v, want Paris at given coordinates", got)
+				}
+			}
+
+			if got := provider.calls.Load(); got != tc.want {
+				t.Errorf("provider calls = %d, want %d", got, tc.want)
+			}
+		})
+	}
+}
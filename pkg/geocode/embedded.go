@@ -0,0 +1,84 @@
+package geocode
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ViBiOh/exas/pkg/model"
+)
+
+const (
+	gpsDateTime      = "GPSDateTime"
+	gpsDatePattern   = "2006:01:02 15:04:05Z07:00"
+	embeddedDocument = "Doc"
+)
+
+// ExtractTrack summarizes the GPS points of embedded documents, as extracted by exiftool with `-ee -G3`, e.g. `Doc1:GPSLatitude`
+func ExtractTrack(data map[string]any) (track model.Track, err error) {
+	documents := make(map[int]map[string]any)
+
+	for key, value := range data {
+		group, tag, ok := strings.Cut(key, ":")
+		if !ok || !strings.HasPrefix(group, embeddedDocument) {
+			continue
+		}
+
+		number, err := strconv.Atoi(strings.TrimPrefix(group, embeddedDocument))
+		if err != nil {
+			continue
+		}
+
+		if documents[number] == nil {
+			documents[number] = make(map[string]any)
+		}
+
+		documents[number][tag] = value
+	}
+
+	var previous model.Geocode
+
+	for _, number := range slices.Sorted(maps.Keys(documents)) {
+		point, err := ExtractLocation(documents[number])
+		if err != nil {
+			return track, fmt.Errorf("document #%d: %w", number, err)
+		}
+
+		if !point.HasCoordinates() {
+			continue
+		}
+
+		if rawDate, ok := documents[number][gpsDateTime].(string); ok {
+			if date, err := time.Parse(gpsDatePattern, rawDate); err == nil {
+				if track.Start.IsZero() || date.Before(track.Start) {
+					track.Start = date
+				}
+
+				if date.After(track.End) {
+					track.End = date
+				}
+			}
+		}
+
+		if track.Points == 0 {
+			track.Bounds = [4]float64{point.Latitude, point.Longitude, point.Latitude, point.Longitude}
+		} else {
+			track.Bounds = [4]float64{
+				min(track.Bounds[0], point.Latitude),
+				min(track.Bounds[1], point.Longitude),
+				max(track.Bounds[2], point.Latitude),
+				max(track.Bounds[3], point.Longitude),
+			}
+
+			track.Distance += haversine(previous.Latitude, previous.Longitude, point.Latitude, point.Longitude)
+		}
+
+		previous = point
+		track.Points++
+	}
+
+	return track, nil
+}
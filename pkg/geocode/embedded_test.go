@@ -0,0 +1,45 @@
+package geocode
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestExtractTrack(t *testing.T) {
+	t.Parallel()
+
+	got, err := ExtractTrack(map[string]any{
+		"Main:GPSLatitude":  "+48.00000000",
+		"Doc2:GPSLatitude":  "+48.01000000",
+		"Doc2:GPSLongitude": "+2.00000000",
+		"Doc2:GPSDateTime":  "2024:06:01 10:00:10.000Z",
+		"Doc1:GPSLatitude":  "+48.00000000",
+		"Doc1:GPSLongitude": "+2.00000000",
+		"Doc1:GPSDateTime":  "2024:06:01 10:00:00.000Z",
+		"Doc3:GPSDateTime":  "2024:06:01 10:00:20.000Z",
+	})
+	if err != nil {
+		t.Fatalf("ExtractTrack: %s", err)
+	}
+
+	if got.Points != 2 {
+		t.Errorf("ExtractTrack() points = %d, want 2", got.Points)
+	}
+
+	if want := time.Date(2024, 6, 1, 10, 0, 0, 0, time.UTC); !got.Start.Equal(want) {
+		t.Errorf("ExtractTrack() start = %s, want %s", got.Start, want)
+	}
+
+	if want := time.Date(2024, 6, 1, 10, 0, 10, 0, time.UTC); !got.End.Equal(want) {
+		t.Errorf("ExtractTrack() end = %s, want %s", got.End, want)
+	}
+
+	if want := [4]float64{48, 2, 48.01, 2}; got.Bounds != want {
+		t.Errorf("ExtractTrack() bounds = %v, want %v", got.Bounds, want)
+	}
+
+	if math.Abs(got.Distance-1112) > 1 {
+		t.Errorf("ExtractTrack() distance = %f, want about 1112", got.Distance)
+	}
+}
@@ -2,19 +2,16 @@ package geocode
 
 import (
 	"context"
+	"errors"
 	"flag"
 	"fmt"
 	"log/slog"
-	"net/url"
-	"regexp"
-	"strconv"
 	"strings"
 	"time"
 
+	absto "github.com/ViBiOh/absto/pkg/model"
 	"github.com/ViBiOh/exas/pkg/model"
 	"github.com/ViBiOh/flags"
-	"github.com/ViBiOh/httputils/v4/pkg/httpjson"
-	"github.com/ViBiOh/httputils/v4/pkg/request"
 	"github.com/ViBiOh/httputils/v4/pkg/telemetry"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/metric"
@@ -22,217 +19,276 @@ import (
 )
 
 const (
-	gpsLatitude  = "GPSLatitude"
-	gpsLongitude = "GPSLongitude"
+	publicNominatimURL  = "https://nominatim.openstreetmap.org"
+	publicNominatimRate = 1 / 1.2 // nominatim allows 1req/sec, so we take an extra step
 
-	publicNominatimURL      = "https://nominatim.openstreetmap.org"
-	publicNominatimInterval = time.Second + time.Millisecond*200 // nominatim allows 1req/sec, so we take an extra step
+	refreshInterval = time.Minute // tracks and places are reloaded from storage at most once per interval
 )
 
-var gpsRegex = regexp.MustCompile(`(?im)([0-9]+)\s*deg\s*([0-9]+)'\s*([0-9]+(?:\.[0-9]+)?)"\s*([NSWE])`)
-
-type reverseGeocodeResponse struct {
-	Address map[string]string `json:"address"`
-}
-
 type Config struct {
-	GeocodeURL string
+	GeocodeProvider string
+	GeocodeURL      string
+	GeocodeAPIKey   string
+	GeocodeLanguage string
+	GeocodeZoom     string
+
+	GeocodeAddressKeys        []string
+	GeocodeExcludeAddressKeys []string
+	GeocodeCoordinatesDecimal int
+	GeocodeDatasets           []string
+	GeocodeTimezoneDatasets   []string
+
+	GeocodeCacheDirectory string
+	GeocodeCacheSize      uint
+	GeocodeCachePrecision uint
+
+	GeocodePlacesFile      string
+	GeocodeTracksDirectory string
+	GeocodeTracksOffset    time.Duration
+	GeocodeTracksMaxGap    time.Duration
+
+	GeocodeRate    float64
+	GeocodeBurst   uint
+	GeocodeMaxWait time.Duration
 }
 
 func Flags(fs *flag.FlagSet, prefix string, overrides ...flags.Override) *Config {
 	var config Config
 
-	flags.New("GeocodeURL", fmt.Sprintf("Nominatim Geocode Service URL. This can leak GPS metadatas to a third-party (e.g. \"%s\")", publicNominatimURL)).Prefix(prefix).DocPrefix("exif").StringVar(fs, &config.GeocodeURL, "", overrides)
+	flags.New("GeocodeProvider", "Geocode provider: nominatim, photon, pelias or offline").Prefix(prefix).DocPrefix("exif").StringVar(fs, &config.GeocodeProvider, providerNominatim, overrides)
+	flags.New("GeocodeURL", fmt.Sprintf("Geocode Service URL. This can leak GPS metadatas to a third-party (e.g. \"%s\")", publicNominatimURL)).Prefix(prefix).DocPrefix("exif").StringVar(fs, &config.GeocodeURL, "", overrides)
+	flags.New("GeocodeAPIKey", "Geocode Service API key, if required by the provider (e.g. Pelias)").Prefix(prefix).DocPrefix("exif").StringVar(fs, &config.GeocodeAPIKey, "", overrides)
+	flags.New("GeocodeLanguage", "Default language of addresses, as an Accept-Language header (e.g. \"fr,en\"), empty for the provider default").Prefix(prefix).DocPrefix("exif").StringVar(fs, &config.GeocodeLanguage, "", overrides)
+	flags.New("GeocodeZoom", "Detail level of addresses: country, state, city, suburb, street, building or a Nominatim zoom from 0 to 18").Prefix(prefix).DocPrefix("exif").StringVar(fs, &config.GeocodeZoom, "building", overrides)
+	flags.New("GeocodeAddressKeys", "Address keys kept, empty for all (e.g. \"city,country\")").Prefix(prefix).DocPrefix("exif").StringSliceVar(fs, &config.GeocodeAddressKeys, nil, overrides)
+	flags.New("GeocodeExcludeAddressKeys", "Address keys removed (e.g. \"house_number\")").Prefix(prefix).DocPrefix("exif").StringSliceVar(fs, &config.GeocodeExcludeAddressKeys, nil, overrides)
+	flags.New("GeocodeCoordinatesDecimal", "Decimals kept in coordinates of geocode, GPS tags and track, -1 to keep them all, 2 is about one kilometer").Prefix(prefix).DocPrefix("exif").IntVar(fs, &config.GeocodeCoordinatesDecimal, -1, overrides)
+	flags.New("GeocodeDatasets", "Local datasets of offline provider: GeoNames dump of cities or postal codes (.txt or .zip), boundaries GeoJSON (.json or .geojson)").Prefix(prefix).DocPrefix("exif").StringSliceVar(fs, &config.GeocodeDatasets, nil, overrides)
+	flags.New("GeocodeTimezoneDatasets", "Local datasets of timezones, as GeoNames dump of cities or boundaries GeoJSON with a tzid property, the embedded boundaries of timezone-boundary-builder are used by default").Prefix(prefix).DocPrefix("exif").StringSliceVar(fs, &config.GeocodeTimezoneDatasets, nil, overrides)
+	flags.New("GeocodeRate", fmt.Sprintf("Requests per second sent to the provider, 0 for unlimited (%.2f for the public Nominatim)", publicNominatimRate)).Prefix(prefix).DocPrefix("exif").Float64Var(fs, &config.GeocodeRate, 0, overrides)
+	flags.New("GeocodeBurst", "Requests sent to the provider at once before being rate limited").Prefix(prefix).DocPrefix("exif").UintVar(fs, &config.GeocodeBurst, 1, overrides)
+	flags.New("GeocodeMaxWait", "Max wait for the rate limit, geocoding is skipped above, 0 to wait indefinitely").Prefix(prefix).DocPrefix("exif").DurationVar(fs, &config.GeocodeMaxWait, 30*time.Second, overrides)
+	flags.New("GeocodeCacheSize", "Number of addresses kept in memory, 0 to disable").Prefix(prefix).DocPrefix("exif").UintVar(fs, &config.GeocodeCacheSize, 10000, overrides)
+	flags.New("GeocodeCachePrecision", "Decimals of coordinates kept in cache key, 3 is about 100 meters").Prefix(prefix).DocPrefix("exif").UintVar(fs, &config.GeocodeCachePrecision, 3, overrides)
+	flags.New("GeocodePlacesFile", "Storage file of known places labelled in geocode, as a YAML or JSON list of circles or polygons, private ones aren't sent to the provider").Prefix(prefix).DocPrefix("exif").StringVar(fs, &config.GeocodePlacesFile, "", overrides)
+	flags.New("GeocodeTracksDirectory", "Storage directory of GPX and KML tracks, used to infer coordinates of files without GPS from their date").Prefix(prefix).DocPrefix("exif").StringVar(fs, &config.GeocodeTracksDirectory, "", overrides)
+	flags.New("GeocodeTracksOffset", "Offset added to the date of files without timezone to match tracks time, e.g. -2h for a camera clock set on UTC+2 without timezone").Prefix(prefix).DocPrefix("exif").DurationVar(fs, &config.GeocodeTracksOffset, 0, overrides)
+	flags.New("GeocodeTracksMaxGap", "Max duration between the date of a file and a track point to infer its coordinates").Prefix(prefix).DocPrefix("exif").DurationVar(fs, &config.GeocodeTracksMaxGap, 5*time.Minute, overrides)
+	flags.New("GeocodeCacheDirectory", "Storage directory where addresses are persisted, empty to keep them in memory only").Prefix(prefix).DocPrefix("exif").StringVar(fs, &config.GeocodeCacheDirectory, "", overrides)
 
 	return &config
 }
 
 type Service struct {
-	metric     metric.Int64Counter
-	ticker     *time.Ticker
-	tracer     trace.Tracer
-	geocodeReq request.Request
+	metric    metric.Int64Counter
+	queue     metric.Int64UpDownCounter
+	provider  Provider
+	cache     *cache
+	limiter   *limiter
+	tracks    *tracks
+	places    *places
+	timezones offline
+	tracer    trace.Tracer
+	language  string
+	filter    addressFilter
+
+	coordinatesFactor float64
 }
 
-func New(config *Config, meterProvider metric.MeterProvider, tracerProvider trace.TracerProvider) Service {
-	var ticker *time.Ticker
-	if strings.HasPrefix(config.GeocodeURL, publicNominatimURL) {
-		ticker = time.NewTicker(publicNominatimInterval)
+func New(config *Config, storage absto.Storage, meterProvider metric.MeterProvider, tracerProvider trace.TracerProvider) (Service, error) {
+	zoom, err := parseZoom(config.GeocodeZoom)
+	if err != nil {
+		return Service{}, err
+	}
+
+	provider, err := newProvider(config, zoom)
+	if err != nil {
+		return Service{}, fmt.Errorf("provider: %w", err)
+	}
+
+	cache, err := newCache(config, storage)
+	if err != nil {
+		return Service{}, fmt.Errorf("cache: %w", err)
+	}
+
+	tracks, err := newTracks(config, storage)
+	if err != nil {
+		return Service{}, fmt.Errorf("tracks: %w", err)
+	}
+
+	timezones, err := newTimezones(config)
+	if err != nil {
+		return Service{}, fmt.Errorf("timezones: %w", err)
+	}
+
+	places, err := newPlaces(config, storage)
+	if err != nil {
+		return Service{}, fmt.Errorf("places: %w", err)
+	}
+
+	rate := config.GeocodeRate
+	if rate == 0 && provider != nil && strings.HasPrefix(config.GeocodeURL, publicNominatimURL) {
+		rate = publicNominatimRate
 	}
 
 	service := Service{
-		geocodeReq: request.New().Header("User-Agent", "fibr, reverse geocoding from exif data").Get(config.GeocodeURL),
-		ticker:     ticker,
+		provider:  provider,
+		cache:     cache,
+		tracks:    tracks,
+		places:    places,
+		timezones: timezones,
+		limiter:   newLimiter(rate, config.GeocodeBurst, config.GeocodeMaxWait),
+		language:  ParseLanguage(config.GeocodeLanguage),
+		filter: addressFilter{
+			zoom:    zoom,
+			include: config.GeocodeAddressKeys,
+			exclude: config.GeocodeExcludeAddressKeys,
+		},
+		coordinatesFactor: coordinatesFactor(config.GeocodeCoordinatesDecimal),
 	}
 
 	if meterProvider != nil {
 		meter := meterProvider.Meter("github.com/ViBiOh/exas/pkg/geocode")
 
-		var err error
-
 		service.metric, err = meter.Int64Counter("exas.geocode")
 		if err != nil {
 			slog.LogAttrs(context.Background(), slog.LevelError, "create geocode counter", slog.Any("error", err))
 		}
+
+		service.queue, err = meter.Int64UpDownCounter("exas.geocode.queue")
+		if err != nil {
+			slog.LogAttrs(context.Background(), slog.LevelError, "create geocode queue counter", slog.Any("error", err))
+		}
 	}
 
 	if tracerProvider != nil {
 		service.tracer = tracerProvider.Tracer("geocode")
 	}
 
-	return service
+	return service, nil
 }
 
+// Enabled reports if coordinates are resolved, by a provider or by known places.
 func (s Service) Enabled() bool {
-	return !s.geocodeReq.IsZero()
+	return s.provider != nil || s.places != nil
 }
 
-func (s Service) Close() {
-	if s.ticker == nil {
-		return
+// Resolve fills the labels of known places and the address of the geocode from its coordinates, the address of private places is never requested.
+// An empty language uses the configured one. ErrLimited is returned when the rate limit would wait too long.
+func (s Service) Resolve(ctx context.Context, geocode model.Geocode, language string) (_ model.Geocode, err error) {
+	if !s.Enabled() || !geocode.HasCoordinates() {
+		return geocode, nil
 	}
 
-	s.ticker.Stop()
-}
-
-func (s Service) GetGeocoding(ctx context.Context, exif model.Exif) (geocode model.Geocode, err error) {
 	ctx, end := telemetry.StartSpan(ctx, s.tracer, "geocode")
 	defer end(&err)
 
-	geocode.Latitude, geocode.Longitude, err = extractCoordinates(exif.Data)
-	if err != nil {
-		return geocode, fmt.Errorf("get gps coordinate: %w", err)
+	if s.places != nil {
+		var private bool
+
+		if geocode.Labels, private, err = s.places.match(ctx, geocode.Latitude, geocode.Longitude); err != nil {
+			s.increaseMetric(ctx, "reverse", "places_error")
+			return geocode, err
+		}
+
+		if private {
+			s.increaseMetric(ctx, "reverse", "private")
+			return geocode, nil
+		}
 	}
 
-	if !s.Enabled() {
-		return geocode, err
+	if s.provider == nil {
+		return geocode, nil
 	}
 
-	if geocode.HasCoordinates() {
-		if s.ticker != nil {
-			<-s.ticker.C
+	if geocode, err = s.getReverseGeocode(ctx, geocode, s.languageOrDefault(language)); err != nil {
+		if errors.Is(err, ErrLimited) {
+			s.increaseMetric(ctx, "reverse", "skipped")
 		}
 
-		if geocode, err = s.getReverseGeocode(ctx, geocode); err != nil {
-			return geocode, fmt.Errorf("reverse geocode: %w", err)
-		}
+		return geocode, fmt.Errorf("reverse geocode: %w", err)
 	}
 
 	if len(geocode.Address) == 0 {
-		s.increaseMetric(ctx, "empty")
+		s.increaseMetric(ctx, "reverse", "empty")
 		return geocode, nil
 	}
 
-	s.increaseMetric(ctx, "success")
+	s.increaseMetric(ctx, "reverse", "success")
 
 	return geocode, nil
 }
 
-func extractCoordinates(data map[string]any) (float64, float64, error) {
-	lat, err := getCoordinate(data, gpsLatitude)
-	if err != nil {
-		return 0, 0, fmt.Errorf("parse latitude: %w", err)
-	}
+func (s Service) getReverseGeocode(ctx context.Context, geocode model.Geocode, language string) (model.Geocode, error) {
+	var key string
 
-	if lat == 0 {
-		return 0, 0, nil
-	}
+	if s.cache != nil {
+		key = s.cache.key(geocode.Latitude, geocode.Longitude, language)
 
-	lon, err := getCoordinate(data, gpsLongitude)
-	if err != nil {
-		return 0, 0, fmt.Errorf("parse longitude: %w", err)
-	}
+		if address, ok := s.cache.get(ctx, key); ok {
+			s.increaseMetric(ctx, "reverse", "cache_hit")
+			geocode.Address = s.filter.apply(address)
 
-	return lat, lon, nil
-}
+			return geocode, nil
+		}
 
-func getCoordinate(data map[string]any, key string) (float64, error) {
-	rawCoordinate, ok := data[key]
-	if !ok {
-		return 0, nil
+		s.increaseMetric(ctx, "reverse", "cache_miss")
 	}
 
-	coordinateStr, ok := rawCoordinate.(string)
-	if !ok {
-		return 0, fmt.Errorf("key `%s` is not a string", key)
+	if err := s.wait(ctx); err != nil {
+		return geocode, err
 	}
 
-	if len(coordinateStr) == 0 {
-		return 0, nil
-	}
+	s.increaseMetric(ctx, "reverse", "requested")
 
-	coordinate, err := convertDegreeMinuteSecondToDecimal(coordinateStr)
+	address, err := s.provider.Reverse(ctx, geocode.Latitude, geocode.Longitude, language)
 	if err != nil {
-		return 0, fmt.Errorf("parse `%s` with value `%s`: %w", key, coordinateStr, err)
-	}
-
-	return coordinate, nil
-}
-
-func convertDegreeMinuteSecondToDecimal(location string) (float64, error) {
-	match := gpsRegex.FindStringSubmatch(location)
-	if len(match) == 0 {
-		return 0, fmt.Errorf("parse GPS data `%s`", location)
-	}
+		if errors.Is(err, errDecode) {
+			s.increaseMetric(ctx, "reverse", "decode_error")
+		} else {
+			s.increaseMetric(ctx, "reverse", "api_error")
+		}
 
-	degrees, err := strconv.ParseFloat(match[1], 32)
-	if err != nil {
-		return 0, fmt.Errorf("parse GPS degrees: %w", err)
+		return geocode, fmt.Errorf("%w: %w", ErrProvider, err)
 	}
 
-	minutes, err := strconv.ParseFloat(match[2], 32)
-	if err != nil {
-		return 0, fmt.Errorf("parse GPS minutes: %w", err)
-	}
+	geocode.Address = s.filter.apply(address)
 
-	seconds, err := strconv.ParseFloat(match[3], 32)
-	if err != nil {
-		return 0, fmt.Errorf("parse GPS seconds: %w", err)
+	if s.cache != nil {
+		s.cache.set(ctx, key, address)
 	}
 
-	direction := match[4]
-
-	dd := degrees + minutes/60 + seconds/3600
+	return geocode, nil
+}
 
-	if direction == "S" || direction == "W" {
-		dd *= -1
+func (s Service) languageOrDefault(language string) string {
+	if len(language) != 0 {
+		return language
 	}
 
-	return dd, nil
+	return s.language
 }
 
-func (s Service) getReverseGeocode(ctx context.Context, geocode model.Geocode) (model.Geocode, error) {
-	params := url.Values{}
-	params.Add("lat", fmt.Sprintf("%.6f", geocode.Latitude))
-	params.Add("lon", fmt.Sprintf("%.6f", geocode.Longitude))
-	params.Add("format", "json")
-	params.Add("zoom", "18")
-
-	s.increaseMetric(ctx, "requested")
-
-	resp, err := s.geocodeReq.Path("/reverse?%s", params.Encode()).Send(ctx, nil)
-	if err != nil {
-		s.increaseMetric(ctx, "api_error")
-		return geocode, fmt.Errorf("get reverse geocoding: %w", err)
+func (s Service) wait(ctx context.Context) error {
+	if s.limiter == nil {
+		return nil
 	}
 
-	reverseGeo, err := httpjson.Read[reverseGeocodeResponse](resp)
-	if err != nil {
-		s.increaseMetric(ctx, "decode_error")
-		return geocode, fmt.Errorf("decode reverse geocoding: %w", err)
+	if s.queue != nil {
+		s.queue.Add(ctx, 1)
+		defer s.queue.Add(ctx, -1)
 	}
 
-	geocode.Address = reverseGeo.Address
-
-	return geocode, nil
+	return s.limiter.wait(ctx)
 }
 
-func (s Service) increaseMetric(ctx context.Context, state string) {
+func (s Service) increaseMetric(ctx context.Context, kind, state string) {
 	if s.metric == nil {
 		return
 	}
 
 	s.metric.Add(ctx, 1, metric.WithAttributes(
+		attribute.String("kind", kind),
 		attribute.String("state", state),
 	))
 }
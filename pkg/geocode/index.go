@@ -0,0 +1,243 @@
+package geocode
+
+import (
+	"cmp"
+	"maps"
+	"math"
+	"slices"
+	"strings"
+
+	"github.com/ViBiOh/exas/pkg/model"
+)
+
+const earthRadius = 6371e3 // in meters
+
+// cell is a one degree square of latitude and longitude
+type cell struct {
+	lat int
+	lon int
+}
+
+func cellOf(latitude, longitude float64) cell {
+	return cell{lat: int(math.Floor(latitude)), lon: int(math.Floor(longitude))}
+}
+
+// neighbours returns the cell and the eight surrounding ones, wrapping around the antimeridian.
+func (c cell) neighbours() []cell {
+	output := make([]cell, 0, 9)
+
+	for lat := c.lat - 1; lat <= c.lat+1; lat++ {
+		for lon := c.lon - 1; lon <= c.lon+1; lon++ {
+			output = append(output, cell{lat: lat, lon: (lon+540)%360 - 180})
+		}
+	}
+
+	return output
+}
+
+type place struct {
+	address    map[string]string
+	timezone   string
+	latitude   float64
+	longitude  float64
+	population int
+}
+
+type area struct {
+	address  map[string]string
+	timezone string
+	polygons [][][][2]float64 // polygons of rings of [lon, lat], the first ring is the outer one, the others are holes
+	bbox     [4]float64       // minLon, minLat, maxLon, maxLat
+}
+
+func (a area) center() (float64, float64) {
+	return (a.bbox[1] + a.bbox[3]) / 2, (a.bbox[0] + a.bbox[2]) / 2
+}
+
+func (a area) size() float64 {
+	return (a.bbox[2] - a.bbox[0]) * (a.bbox[3] - a.bbox[1])
+}
+
+func (a area) contains(latitude, longitude float64) bool {
+	if longitude < a.bbox[0] || latitude < a.bbox[1] || longitude > a.bbox[2] || latitude > a.bbox[3] {
+		return false
+	}
+
+	for _, polygon := range a.polygons {
+		if len(polygon) == 0 || !ringContains(polygon[0], latitude, longitude) {
+			continue
+		}
+
+		inHole := false
+		for _, hole := range polygon[1:] {
+			if ringContains(hole, latitude, longitude) {
+				inHole = true
+				break
+			}
+		}
+
+		if !inHole {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ringContains is the ray casting algorithm
+func ringContains(ring [][2]float64, latitude, longitude float64) bool {
+	inside := false
+
+	for i, j := 0, len(ring)-1; i < len(ring); j, i = i, i+1 {
+		xi, yi := ring[i][0], ring[i][1]
+		xj, yj := ring[j][0], ring[j][1]
+
+		if (yi > latitude) != (yj > latitude) && longitude < (xj-xi)*(latitude-yi)/(yj-yi)+xi {
+			inside = !inside
+		}
+	}
+
+	return inside
+}
+
+// index is a grid of one degree cells, holding the places and areas overlapping them
+type index struct {
+	places    map[cell][]place
+	areas     map[cell][]*area
+	allPlaces []place
+	allAreas  []*area
+}
+
+func newIndex() *index {
+	return &index{
+		places: make(map[cell][]place),
+		areas:  make(map[cell][]*area),
+	}
+}
+
+func (i *index) addPlace(item place) {
+	key := cellOf(item.latitude, item.longitude)
+	i.places[key] = append(i.places[key], item)
+	i.allPlaces = append(i.allPlaces, item)
+}
+
+func (i *index) addArea(item *area) {
+	i.allAreas = append(i.allAreas, item)
+
+	minCell := cellOf(item.bbox[1], item.bbox[0])
+	maxCell := cellOf(item.bbox[3], item.bbox[2])
+
+	for lat := minCell.lat; lat <= maxCell.lat; lat++ {
+		for lon := minCell.lon; lon <= maxCell.lon; lon++ {
+			key := cell{lat: lat, lon: lon}
+			i.areas[key] = append(i.areas[key], item)
+		}
+	}
+}
+
+// nearest returns the closest place, searched in the surrounding cells only.
+func (i *index) nearest(latitude, longitude float64, country string) (place, bool) {
+	var output place
+
+	found := false
+	distance := math.MaxFloat64
+
+	for _, key := range cellOf(latitude, longitude).neighbours() {
+		for _, item := range i.places[key] {
+			if len(country) != 0 && item.address[countryCode] != country {
+				continue
+			}
+
+			if current := haversine(latitude, longitude, item.latitude, item.longitude); current < distance {
+				output = item
+				distance = current
+				found = true
+			}
+		}
+	}
+
+	return output, found
+}
+
+// containing returns the areas containing the point, from the largest to the smallest.
+func (i *index) containing(latitude, longitude float64) []*area {
+	var output []*area
+
+	for _, item := range i.areas[cellOf(latitude, longitude)] {
+		if item.contains(latitude, longitude) {
+			output = append(output, item)
+		}
+	}
+
+	slices.SortStableFunc(output, func(a, b *area) int {
+		return cmp.Compare(b.size(), a.size())
+	})
+
+	return output
+}
+
+// matching returns the areas then the places having an address value equal to the query, from the largest to the smallest.
+func (i *index) matching(query string, limit int) []model.Geocode {
+	var areas []*area
+
+	for _, item := range i.allAreas {
+		if addressMatches(item.address, query) {
+			areas = append(areas, item)
+		}
+	}
+
+	slices.SortStableFunc(areas, func(a, b *area) int {
+		return cmp.Compare(b.size(), a.size())
+	})
+
+	var places []place
+
+	for _, item := range i.allPlaces {
+		if addressMatches(item.address, query) {
+			places = append(places, item)
+		}
+	}
+
+	slices.SortStableFunc(places, func(a, b place) int {
+		return cmp.Compare(b.population, a.population)
+	})
+
+	output := make([]model.Geocode, 0, min(limit, len(areas)+len(places)))
+
+	for _, item := range areas {
+		latitude, longitude := item.center()
+		output = append(output, model.Geocode{Address: maps.Clone(item.address), Latitude: latitude, Longitude: longitude})
+	}
+
+	for _, item := range places {
+		output = append(output, model.Geocode{Address: maps.Clone(item.address), Latitude: item.latitude, Longitude: item.longitude})
+	}
+
+	if len(output) > limit {
+		output = output[:limit]
+	}
+
+	return output
+}
+
+func addressMatches(address map[string]string, query string) bool {
+	for _, value := range address {
+		if strings.EqualFold(value, query) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func haversine(latA, lonA, latB, lonB float64) float64 {
+	latA, lonA, latB, lonB = radians(latA), radians(lonA), radians(latB), radians(lonB)
+
+	a := math.Pow(math.Sin((latB-latA)/2), 2) + math.Cos(latA)*math.Cos(latB)*math.Pow(math.Sin((lonB-lonA)/2), 2)
+
+	return 2 * earthRadius * math.Asin(math.Sqrt(a))
+}
+
+func radians(degrees float64) float64 {
+	return degrees * math.Pi / 180
+}
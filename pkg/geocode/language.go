@@ -0,0 +1,91 @@
+package geocode
+
+import (
+	"cmp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+const maxLanguages = 3
+
+type weightedLanguage struct {
+	tag    string
+	weight float64
+}
+
+// ParseLanguage normalizes an `Accept-Language` header to the comma-separated list of its preferred tags, e.g. `fr-fr,fr,en`
+func ParseLanguage(header string) string {
+	var languages []weightedLanguage
+
+	for part := range strings.SplitSeq(header, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		tag = strings.ToLower(strings.TrimSpace(tag))
+
+		if !isLanguageTag(tag) {
+			continue
+		}
+
+		weight := 1.0
+
+		if value, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				continue
+			}
+
+			weight = parsed
+		}
+
+		if weight > 0 {
+			languages = append(languages, weightedLanguage{tag: tag, weight: weight})
+		}
+	}
+
+	slices.SortStableFunc(languages, func(a, b weightedLanguage) int {
+		return cmp.Compare(b.weight, a.weight)
+	})
+
+	var output []string
+
+	for _, language := range languages {
+		if !slices.Contains(output, language.tag) {
+			output = append(output, language.tag)
+		}
+
+		if len(output) == maxLanguages {
+			break
+		}
+	}
+
+	return strings.Join(output, ",")
+}
+
+func isLanguageTag(tag string) bool {
+	if len(tag) == 0 || len(tag) > 35 || tag[0] == '-' {
+		return false
+	}
+
+	for _, char := range tag {
+		if (char < 'a' || char > 'z') && (char < '0' || char > '9') && char != '-' {
+			return false
+		}
+	}
+
+	return true
+}
+
+// primaryLanguage returns the first language of the list, without region, e.g. `fr` for `fr-fr,en`
+func primaryLanguage(language string) string {
+	first, _, _ := strings.Cut(language, ",")
+	primary, _, _ := strings.Cut(first, "-")
+
+	return primary
+}
+
+// firstLanguage returns the first language of the list, e.g. `fr-fr` for `fr-fr,en`
+func firstLanguage(language string) string {
+	first, _, _ := strings.Cut(language, ",")
+
+	return first
+}
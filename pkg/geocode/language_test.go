@@ -0,0 +1,57 @@
+package geocode
+
+import "testing"
+
+func TestParseLanguage(t *testing.T) {
+	t.Parallel()
+
+	type args struct {
+		header string
+	}
+
+	cases := map[string]struct {
+		args args
+		want string
+	}{
+		"empty": {
+			args{
+				header: "",
+			},
+			"",
+		},
+		"simple": {
+			args{
+				header: "fr",
+			},
+			"fr",
+		},
+		"weighted": {
+			args{
+				header: "en;q=0.5, fr-FR, fr;q=0.9, de;q=0",
+			},
+			"fr-fr,fr,en",
+		},
+		"limited": {
+			args{
+				header: "fr, en, de, it",
+			},
+			"fr,en,de",
+		},
+		"invalid": {
+			args{
+				header: "*, ../../etc, fr;q=abc, en_US",
+			},
+			"",
+		},
+	}
+
+	for intention, tc := range cases {
+		t.Run(intention, func(t *testing.T) {
+			t.Parallel()
+
+			if got := ParseLanguage(tc.args.header); got != tc.want {
+				t.Errorf("ParseLanguage() = `%s`, want `%s`", got, tc.want)
+			}
+		})
+	}
+}
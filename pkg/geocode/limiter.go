@@ -0,0 +1,94 @@
+package geocode
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ErrLimited is returned when the rate limit would delay a request longer than allowed
+var ErrLimited = errors.New("rate limited")
+
+// limiter is a token bucket shared by every copy of the Service. Tokens are reserved under lock, so callers are served in order of arrival.
+type limiter struct {
+	last     time.Time
+	interval time.Duration
+	maxWait  time.Duration
+	tokens   float64
+	burst    float64
+	mutex    sync.Mutex
+}
+
+func newLimiter(rate float64, burst uint, maxWait time.Duration) *limiter {
+	if rate <= 0 {
+		return nil
+	}
+
+	if burst == 0 {
+		burst = 1
+	}
+
+	return &limiter{
+		interval: time.Duration(float64(time.Second) / rate),
+		maxWait:  maxWait,
+		tokens:   float64(burst),
+		burst:    float64(burst),
+	}
+}
+
+// reserve takes a token and returns the delay before using it, or false if the delay is above the max wait.
+func (l *limiter) reserve(now time.Time) (time.Duration, bool) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	if !l.last.IsZero() {
+		l.tokens = min(l.burst, l.tokens+float64(now.Sub(l.last))/float64(l.interval))
+	}
+
+	l.last = now
+
+	var delay time.Duration
+	if l.tokens < 1 {
+		delay = time.Duration((1 - l.tokens) * float64(l.interval))
+	}
+
+	if l.maxWait > 0 && delay > l.maxWait {
+		return delay, false
+	}
+
+	l.tokens--
+
+	return delay, true
+}
+
+// release gives back an unused token.
+func (l *limiter) release() {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	l.tokens = min(l.burst, l.tokens+1)
+}
+
+func (l *limiter) wait(ctx context.Context) error {
+	delay, ok := l.reserve(time.Now())
+	if !ok {
+		return fmt.Errorf("wait of %s above %s: %w", delay, l.maxWait, ErrLimited)
+	}
+
+	if delay == 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		l.release()
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
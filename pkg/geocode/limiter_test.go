@@ -0,0 +1,91 @@
+package geocode
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestLimiterReserve(t *testing.T) {
+	t.Parallel()
+
+	type args struct {
+		burst   uint
+		maxWait time.Duration
+		calls   int
+	}
+
+	cases := map[string]struct {
+		args      args
+		wantDelay time.Duration
+		wantOk    bool
+	}{
+		"burst": {
+			args{
+				burst: 3,
+				calls: 3,
+			},
+			0,
+			true,
+		},
+		"queued": {
+			args{
+				burst: 1,
+				calls: 3,
+			},
+			2 * time.Second,
+			true,
+		},
+		"above max wait": {
+			args{
+				burst:   1,
+				maxWait: time.Second,
+				calls:   3,
+			},
+			2 * time.Second,
+			false,
+		},
+	}
+
+	for intention, tc := range cases {
+		t.Run(intention, func(t *testing.T) {
+			t.Parallel()
+
+			instance := newLimiter(1, tc.args.burst, tc.args.maxWait)
+			now := time.Now()
+
+			var gotDelay time.Duration
+			var gotOk bool
+
+			for range tc.args.calls {
+				gotDelay, gotOk = instance.reserve(now)
+			}
+
+			if gotDelay != tc.wantDelay || gotOk != tc.wantOk {
+				t.Errorf("reserve() = (%s, %t), want (%s, %t)", gotDelay, gotOk, tc.wantDelay, tc.wantOk)
+			}
+		})
+	}
+}
+
+func TestLimiterWait(t *testing.T) {
+	t.Parallel()
+
+	instance := newLimiter(0.1, 1, time.Minute)
+
+	if err := instance.wait(context.Background()); err != nil {
+		t.Fatalf("first wait: %s", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	if err := instance.wait(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("wait() = %v, want %s", err, context.DeadlineExceeded)
+	}
+
+	if instance.tokens < -0.01 {
+		t.Errorf("tokens = %f, want cancelled reservation to be released", instance.tokens)
+	}
+}
@@ -0,0 +1,235 @@
+package geocode
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/ViBiOh/exas/pkg/model"
+)
+
+const (
+	gpsLatitude          = "GPSLatitude"
+	gpsLongitude         = "GPSLongitude"
+	gpsPosition          = "GPSPosition"
+	gpsCoordinates       = "GPSCoordinates"
+	gpsAltitude          = "GPSAltitude"
+	gpsAltitudeRef       = "GPSAltitudeRef"
+	gpsImgDirection      = "GPSImgDirection"
+	gpsHPositioningError = "GPSHPositioningError"
+
+	belowSeaLevel = "Below Sea Level"
+)
+
+// gpsRegex handles the human format of exiftool, when coordinates were not extracted as decimal degrees
+var gpsRegex = regexp.MustCompile(`(?im)([0-9]+)\s*deg\s*([0-9]+)'\s*([0-9]+(?:\.[0-9]+)?)"\s*([NSWE])`)
+
+// ExtractLocation reads the location from the EXIF/XMP `GPSLatitude` and `GPSLongitude` tags, then
+// from the `GPSPosition` composite or the QuickTime `GPSCoordinates` used by phones' videos.
+func ExtractLocation(data map[string]any) (geocode model.Geocode, err error) {
+	geocode.Latitude, err = getCoordinate(data, gpsLatitude)
+	if err != nil {
+		return geocode, fmt.Errorf("parse latitude: %w", err)
+	}
+
+	geocode.Longitude, err = getCoordinate(data, gpsLongitude)
+	if err != nil {
+		return geocode, fmt.Errorf("parse longitude: %w", err)
+	}
+
+	if geocode.Latitude == 0 && geocode.Longitude == 0 {
+		for _, key := range []string{gpsPosition, gpsCoordinates} {
+			if geocode, err = getPosition(data, key); err != nil {
+				return geocode, fmt.Errorf("parse `%s`: %w", key, err)
+			}
+
+			if geocode.HasCoordinates() {
+				break
+			}
+		}
+	}
+
+	if !geocode.HasCoordinates() {
+		return model.Geocode{}, nil
+	}
+
+	if altitude, ok := getAltitude(data); ok {
+		geocode.Altitude = altitude
+	}
+
+	geocode.Bearing, _ = getNumber(data[gpsImgDirection])
+	geocode.Accuracy, _ = getNumber(data[gpsHPositioningError])
+
+	return geocode, nil
+}
+
+func getCoordinate(data map[string]any, key string) (float64, error) {
+	coordinate, err := parseCoordinate(data[key])
+	if err != nil {
+		return 0, fmt.Errorf("parse `%s` with value `%v`: %w", key, data[key], err)
+	}
+
+	// EXIF tags are unsigned, the hemisphere is in the reference tag
+	if ref, ok := data[key+"Ref"].(string); ok && coordinate > 0 {
+		switch strings.ToUpper(ref) {
+		case "S", "SOUTH", "W", "WEST":
+			coordinate = -coordinate
+		}
+	}
+
+	return coordinate, nil
+}
+
+// getPosition parses a `lat, lon[, altitude]` value.
+func getPosition(data map[string]any, key string) (geocode model.Geocode, err error) {
+	position, ok := data[key].(string)
+	if !ok || len(position) == 0 {
+		return geocode, nil
+	}
+
+	parts := strings.Split(position, ",")
+	if len(parts) < 2 {
+		return geocode, fmt.Errorf("not enough parts in `%s`", position)
+	}
+
+	if geocode.Latitude, err = parseCoordinate(parts[0]); err != nil {
+		return geocode, fmt.Errorf("latitude: %w", err)
+	}
+
+	if geocode.Longitude, err = parseCoordinate(parts[1]); err != nil {
+		return geocode, fmt.Errorf("longitude: %w", err)
+	}
+
+	if len(parts) > 2 {
+		geocode.Altitude, _ = parseAltitude(parts[2])
+	}
+
+	return geocode, nil
+}
+
+func parseCoordinate(value any) (float64, error) {
+	switch typed := value.(type) {
+	case nil:
+		return 0, nil
+	case float64:
+		return typed, nil
+	case string:
+		typed = strings.TrimSpace(typed)
+		if len(typed) == 0 {
+			return 0, nil
+		}
+
+		if gpsRegex.MatchString(typed) {
+			return convertDegreeMinuteSecondToDecimal(typed)
+		}
+
+		number, ref, _ := strings.Cut(typed, " ")
+
+		coordinate, err := strconv.ParseFloat(number, 64)
+		if err != nil || math.IsNaN(coordinate) || math.IsInf(coordinate, 0) {
+			return 0, fmt.Errorf("parse GPS data `%s`", typed)
+		}
+
+		switch strings.TrimSpace(ref) {
+		case "S", "W":
+			coordinate = -math.Abs(coordinate)
+		}
+
+		return coordinate, nil
+	default:
+		return 0, fmt.Errorf("unhandled type `%T`", value)
+	}
+}
+
+func convertDegreeMinuteSecondToDecimal(location string) (float64, error) {
+	match := gpsRegex.FindStringSubmatch(location)
+	if len(match) == 0 {
+		return 0, fmt.Errorf("parse GPS data `%s`", location)
+	}
+
+	degrees, err := strconv.ParseFloat(match[1], 64)
+	if err != nil {
+		return 0, fmt.Errorf("parse GPS degrees: %w", err)
+	}
+
+	minutes, err := strconv.ParseFloat(match[2], 64)
+	if err != nil {
+		return 0, fmt.Errorf("parse GPS minutes: %w", err)
+	}
+
+	seconds, err := strconv.ParseFloat(match[3], 64)
+	if err != nil {
+		return 0, fmt.Errorf("parse GPS seconds: %w", err)
+	}
+
+	direction := match[4]
+
+	dd := degrees + minutes/60 + seconds/3600
+
+	if direction == "S" || direction == "W" {
+		dd *= -1
+	}
+
+	return dd, nil
+}
+
+func getAltitude(data map[string]any) (float64, bool) {
+	altitude, ok := getNumber(data[gpsAltitude])
+	if !ok {
+		return 0, false
+	}
+
+	below := false
+
+	switch ref := data[gpsAltitudeRef].(type) {
+	case string:
+		below = ref == belowSeaLevel
+	case float64:
+		below = ref == 1
+	}
+
+	if printed, ok := data[gpsAltitude].(string); ok && strings.Contains(printed, belowSeaLevel) {
+		below = true
+	}
+
+	if below {
+		altitude = -math.Abs(altitude)
+	}
+
+	return altitude, true
+}
+
+// parseAltitude handles the `35.2 m Above Sea Level` format.
+func parseAltitude(value string) (float64, bool) {
+	altitude, ok := getNumber(value)
+	if !ok {
+		return 0, false
+	}
+
+	if strings.Contains(value, belowSeaLevel) {
+		altitude = -math.Abs(altitude)
+	}
+
+	return altitude, true
+}
+
+// getNumber handles numeric values and printed values with a unit, e.g. `5 m` or `123.45`.
+func getNumber(value any) (float64, bool) {
+	switch typed := value.(type) {
+	case float64:
+		return typed, true
+	case string:
+		number, _, _ := strings.Cut(strings.TrimSpace(typed), " ")
+
+		output, err := strconv.ParseFloat(number, 64)
+		if err != nil || math.IsNaN(output) || math.IsInf(output, 0) {
+			return 0, false
+		}
+
+		return output, true
+	default:
+		return 0, false
+	}
+}
@@ -0,0 +1,197 @@
+package geocode
+
+import (
+	"errors"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ViBiOh/exas/pkg/model"
+)
+
+const float64EqualityThreshold = 0.000001
+
+func TestConvertDegreeMinuteSecondToDecimal(t *testing.T) {
+	t.Parallel()
+
+	type args struct {
+		location string
+	}
+
+	cases := map[string]struct {
+		args    args
+		want    float64
+		wantErr error
+	}{
+		"empty": {
+			args{
+				location: "",
+			},
+			0,
+			errors.New("parse GPS data"),
+		},
+		"north": {
+			args{
+				location: "1 deg 2' 3\" N",
+			},
+			1.034167,
+			nil,
+		},
+		"west": {
+			args{
+				location: "1 deg 2' 3\" W",
+			},
+			-1.034167,
+			nil,
+		},
+	}
+
+	for intention, tc := range cases {
+		t.Run(intention, func(t *testing.T) {
+			t.Parallel()
+
+			got, gotErr := convertDegreeMinuteSecondToDecimal(tc.args.location)
+
+			switch {
+			case tc.wantErr == nil && gotErr != nil:
+				t.Errorf("unexpected error: %s", gotErr)
+			case tc.wantErr != nil && gotErr == nil:
+				t.Errorf("expected error containing %q, got nil", tc.wantErr)
+			case tc.wantErr != nil && !strings.Contains(gotErr.Error(), tc.wantErr.Error()):
+				t.Errorf("error = %q, want containing %q", gotErr, tc.wantErr)
+			}
+
+			if math.Abs(got-tc.want) > float64EqualityThreshold {
+				t.Errorf("result = %f, want %f", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestExtractLocation(t *testing.T) {
+	t.Parallel()
+
+	type args struct {
+		data map[string]any
+	}
+
+	cases := map[string]struct {
+		args    args
+		want    model.Geocode
+		wantErr error
+	}{
+		"empty": {
+			args{
+				data: map[string]any{},
+			},
+			model.Geocode{},
+			nil,
+		},
+		"signed decimal": {
+			args{
+				data: map[string]any{
+					"GPSLatitude":          "+48.85837000",
+					"GPSLongitude":         "-2.29448123",
+					"GPSAltitude":          "35.2 m Above Sea Level",
+					"GPSImgDirection":      123.45,
+					"GPSHPositioningError": "5 m",
+				},
+			},
+			model.Geocode{
+				Latitude:  48.85837,
+				Longitude: -2.29448123,
+				Altitude:  35.2,
+				Bearing:   123.45,
+				Accuracy:  5,
+			},
+			nil,
+		},
+		"numeric with ref": {
+			args{
+				data: map[string]any{
+					"GPSLatitude":     33.8688,
+					"GPSLatitudeRef":  "South",
+					"GPSLongitude":    151.2093,
+					"GPSLongitudeRef": "East",
+					"GPSAltitude":     12.0,
+					"GPSAltitudeRef":  1.0,
+				},
+			},
+			model.Geocode{
+				Latitude:  -33.8688,
+				Longitude: 151.2093,
+				Altitude:  -12,
+			},
+			nil,
+		},
+		"degree minute second": {
+			args{
+				data: map[string]any{
+					"GPSLatitude":  "1 deg 2' 3\" N",
+					"GPSLongitude": "1 deg 2' 3\" W",
+				},
+			},
+			model.Geocode{
+				Latitude:  1.0341666666666667,
+				Longitude: -1.0341666666666667,
+			},
+			nil,
+		},
+		"quicktime": {
+			args{
+				data: map[string]any{
+					"GPSCoordinates": "+48.85837000, +2.29448000, 42 m Below Sea Level",
+				},
+			},
+			model.Geocode{
+				Latitude:  48.85837,
+				Longitude: 2.29448,
+				Altitude:  -42,
+			},
+			nil,
+		},
+		"position": {
+			args{
+				data: map[string]any{
+					"GPSPosition": "48.858370 N, 2.294480 W",
+				},
+			},
+			model.Geocode{
+				Latitude:  48.85837,
+				Longitude: -2.29448,
+			},
+			nil,
+		},
+		"invalid": {
+			args{
+				data: map[string]any{
+					"GPSLatitude": "north",
+				},
+			},
+			model.Geocode{},
+			errors.New("parse latitude"),
+		},
+	}
+
+	for intention, tc := range cases {
+		t.Run(intention, func(t *testing.T) {
+			t.Parallel()
+
+			got, gotErr := ExtractLocation(tc.args.data)
+
+			switch {
+			case tc.wantErr == nil && gotErr != nil:
+				t.Errorf("unexpected error: %s", gotErr)
+			case tc.wantErr != nil && gotErr == nil:
+				t.Errorf("expected error containing %q, got nil", tc.wantErr)
+			case tc.wantErr != nil && !strings.Contains(gotErr.Error(), tc.wantErr.Error()):
+				t.Errorf("error = %q, want containing %q", gotErr, tc.wantErr)
+			}
+
+			if tc.wantErr == nil && !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("ExtractLocation() = %+v, want %+v", got, tc.want)
+			}
+		})
+	}
+}
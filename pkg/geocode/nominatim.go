@@ -0,0 +1,91 @@
+package geocode
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+
+	"github.com/ViBiOh/exas/pkg/model"
+	"github.com/ViBiOh/httputils/v4/pkg/httpjson"
+	"github.com/ViBiOh/httputils/v4/pkg/request"
+)
+
+type nominatimResponse struct {
+	Address   map[string]string `json:"address"`
+	Latitude  string            `json:"lat"`
+	Longitude string            `json:"lon"`
+}
+
+type nominatim struct {
+	req  request.Request
+	zoom int
+}
+
+func (n nominatim) Reverse(ctx context.Context, latitude, longitude float64, language string) (map[string]string, error) {
+	params := url.Values{}
+	params.Add("lat", fmt.Sprintf("%.6f", latitude))
+	params.Add("lon", fmt.Sprintf("%.6f", longitude))
+	params.Add("format", "json")
+	params.Add("zoom", strconv.Itoa(n.zoom))
+
+	if len(language) != 0 {
+		params.Add("accept-language", language)
+	}
+
+	resp, err := n.req.Path("/reverse?%s", params.Encode()).Send(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("get reverse geocoding: %w", err)
+	}
+
+	reverseGeo, err := httpjson.Read[nominatimResponse](resp)
+	if err != nil {
+		return nil, fmt.Errorf("decode reverse geocoding: %w: %w", errDecode, err)
+	}
+
+	return reverseGeo.Address, nil
+}
+
+func (n nominatim) Search(ctx context.Context, query string, limit int, language string) ([]model.Geocode, error) {
+	params := url.Values{}
+	params.Add("q", query)
+	params.Add("format", "json")
+	params.Add("addressdetails", "1")
+	params.Add("limit", strconv.Itoa(limit))
+
+	if len(language) != 0 {
+		params.Add("accept-language", language)
+	}
+
+	resp, err := n.req.Path("/search?%s", params.Encode()).Send(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("search: %w", err)
+	}
+
+	places, err := httpjson.Read[[]nominatimResponse](resp)
+	if err != nil {
+		return nil, fmt.Errorf("decode search: %w: %w", errDecode, err)
+	}
+
+	output := make([]model.Geocode, 0, len(places))
+
+	for _, place := range places {
+		latitude, err := strconv.ParseFloat(place.Latitude, 64)
+		if err != nil {
+			return nil, fmt.Errorf("parse latitude: %w: %w", errDecode, err)
+		}
+
+		longitude, err := strconv.ParseFloat(place.Longitude, 64)
+		if err != nil {
+			return nil, fmt.Errorf("parse longitude: %w: %w", errDecode, err)
+		}
+
+		output = append(output, model.Geocode{
+			Address:   place.Address,
+			Latitude:  latitude,
+			Longitude: longitude,
+		})
+	}
+
+	return output, nil
+}
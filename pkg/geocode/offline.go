@@ -0,0 +1,336 @@
+package geocode
+
+import (
+	"archive/zip"
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/ViBiOh/exas/pkg/model"
+)
+
+const (
+	geonamesCitiesColumns = 19
+	geonamesPostalColumns = 12
+	countryCode           = "country_code"
+)
+
+// boundaryKeys are the properties of a boundary feature copied to the address, aliases are mapped to the Nominatim key
+var boundaryKeys = map[string]string{
+	"house_number": "house_number",
+	"road":         "road",
+	"suburb":       "suburb",
+	"city":         "city",
+	"town":         "town",
+	"village":      "village",
+	"county":       "county",
+	"state":        "state",
+	"postcode":     "postcode",
+	"country":      "country",
+	"country_code": "country_code",
+	"iso_a2":       "country_code",
+	"ISO_A2":       "country_code",
+	"ISO3166-1":    "country_code",
+}
+
+// timezoneKeys are the properties of a boundary feature holding its IANA timezone, e.g. in timezone-boundary-builder releases
+var timezoneKeys = map[string]struct{}{
+	"tzid":     {},
+	"timezone": {},
+}
+
+// offline resolves coordinates from a local dataset loaded in memory, without any network call.
+// Points of a GeoNames dump are resolved to the nearest place, polygons of a GeoJSON to the containing boundaries.
+type offline struct {
+	index *index
+}
+
+func newOffline(datasets []string) (offline, error) {
+	if len(datasets) == 0 {
+		return offline{}, errors.New("no dataset provided")
+	}
+
+	output := offline{index: newIndex()}
+
+	for _, dataset := range datasets {
+		if err := output.load(dataset); err != nil {
+			return output, fmt.Errorf("load `%s`: %w", dataset, err)
+		}
+	}
+
+	return output, nil
+}
+
+// Reverse ignores the language, names are the ones of the dataset. The nearest place is searched in the country of the containing boundaries, if any.
+func (o offline) Reverse(_ context.Context, latitude, longitude float64, _ string) (map[string]string, error) {
+	address := make(map[string]string)
+
+	for _, item := range o.index.containing(latitude, longitude) {
+		maps.Copy(address, item.address)
+	}
+
+	if item, ok := o.index.nearest(latitude, longitude, address[countryCode]); ok {
+		for key, value := range item.address {
+			if _, ok := address[key]; !ok {
+				address[key] = value
+			}
+		}
+	}
+
+	if len(address) == 0 {
+		return nil, nil
+	}
+
+	return address, nil
+}
+
+// Timezone returns the IANA timezone of the smallest containing boundary, or else of the nearest place
+func (o offline) Timezone(latitude, longitude float64) string {
+	areas := o.index.containing(latitude, longitude)
+
+	for _, item := range slices.Backward(areas) {
+		if len(item.timezone) != 0 {
+			return item.timezone
+		}
+	}
+
+	if item, ok := o.index.nearest(latitude, longitude, ""); ok {
+		return item.timezone
+	}
+
+	return ""
+}
+
+func (o offline) Search(_ context.Context, query string, limit int, _ string) ([]model.Geocode, error) {
+	return o.index.matching(strings.TrimSpace(query), limit), nil
+}
+
+func (o offline) load(dataset string) error {
+	file, err := os.Open(dataset)
+	if err != nil {
+		return fmt.Errorf("open: %w", err)
+	}
+	defer func() { _ = file.Close() }()
+
+	switch strings.ToLower(filepath.Ext(dataset)) {
+	case ".zip":
+		return o.loadZip(file)
+	case ".json", ".geojson":
+		return o.loadBoundaries(file)
+	default:
+		return o.loadGeonames(file)
+	}
+}
+
+// loadZip reads the dumps as distributed by GeoNames, e.g. `cities1000.zip`
+func (o offline) loadZip(file *os.File) error {
+	info, err := file.Stat()
+	if err != nil {
+		return fmt.Errorf("stat: %w", err)
+	}
+
+	archive, err := zip.NewReader(file, info.Size())
+	if err != nil {
+		return fmt.Errorf("zip: %w", err)
+	}
+
+	for _, entry := range archive.File {
+		if filepath.Ext(entry.Name) != ".txt" || strings.EqualFold(entry.Name, "readme.txt") {
+			continue
+		}
+
+		reader, err := entry.Open()
+		if err != nil {
+			return fmt.Errorf("open `%s`: %w", entry.Name, err)
+		}
+
+		err = o.loadGeonames(reader)
+		_ = reader.Close()
+
+		if err != nil {
+			return fmt.Errorf("`%s`: %w", entry.Name, err)
+		}
+	}
+
+	return nil
+}
+
+// loadGeonames handles the cities dump (e.g. `cities1000.txt`) and the postal codes dump (e.g. `allCountries.txt` of `/export/zip/`)
+func (o offline) loadGeonames(reader io.Reader) error {
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+
+		item, err := parseGeonames(strings.Split(scanner.Text(), "\t"))
+		if err != nil {
+			return fmt.Errorf("line %d: %w", line, err)
+		}
+
+		o.index.addPlace(item)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("scan: %w", err)
+	}
+
+	return nil
+}
+
+func parseGeonames(columns []string) (item place, err error) {
+	item.address = make(map[string]string)
+
+	var latitude, longitude string
+
+	switch len(columns) {
+	case geonamesCitiesColumns:
+		latitude, longitude = columns[4], columns[5]
+
+		setAddress(item.address, "city", columns[1])
+		setAddress(item.address, countryCode, strings.ToLower(columns[8]))
+		item.timezone = columns[17]
+
+		if len(columns[14]) != 0 {
+			if item.population, err = strconv.Atoi(columns[14]); err != nil {
+				return item, fmt.Errorf("parse population: %w", err)
+			}
+		}
+	case geonamesPostalColumns:
+		latitude, longitude = columns[9], columns[10]
+
+		setAddress(item.address, "postcode", columns[1])
+		setAddress(item.address, "city", columns[2])
+		setAddress(item.address, "state", columns[3])
+		setAddress(item.address, "county", columns[5])
+		setAddress(item.address, countryCode, strings.ToLower(columns[0]))
+	default:
+		return item, fmt.Errorf("unhandled format of %d columns", len(columns))
+	}
+
+	if item.latitude, err = strconv.ParseFloat(latitude, 64); err != nil {
+		return item, fmt.Errorf("parse latitude: %w", err)
+	}
+
+	if item.longitude, err = strconv.ParseFloat(longitude, 64); err != nil {
+		return item, fmt.Errorf("parse longitude: %w", err)
+	}
+
+	return item, nil
+}
+
+type geoJSONFeature struct {
+	Properties map[string]any `json:"properties"`
+	Geometry   struct {
+		Type        string          `json:"type"`
+		Coordinates json.RawMessage `json:"coordinates"`
+	} `json:"geometry"`
+}
+
+type geoJSON struct {
+	Features []geoJSONFeature `json:"features"`
+}
+
+// loadBoundaries handles a GeoJSON FeatureCollection of Polygon and MultiPolygon, with properties named after the address keys
+func (o offline) loadBoundaries(reader io.Reader) error {
+	var collection geoJSON
+	if err := json.NewDecoder(reader).Decode(&collection); err != nil {
+		return fmt.Errorf("decode: %w", err)
+	}
+
+	for i, feature := range collection.Features {
+		item, err := parseBoundary(feature)
+		if err != nil {
+			return fmt.Errorf("feature #%d: %w", i, err)
+		}
+
+		if len(item.polygons) != 0 && (len(item.address) != 0 || len(item.timezone) != 0) {
+			o.index.addArea(&item)
+		}
+	}
+
+	return nil
+}
+
+func parseBoundary(feature geoJSONFeature) (item area, err error) {
+	var polygons [][][][]float64
+
+	switch feature.Geometry.Type {
+	case "Polygon":
+		var polygon [][][]float64
+		if err = json.Unmarshal(feature.Geometry.Coordinates, &polygon); err != nil {
+			return item, fmt.Errorf("decode polygon: %w", err)
+		}
+
+		polygons = append(polygons, polygon)
+	case "MultiPolygon":
+		if err = json.Unmarshal(feature.Geometry.Coordinates, &polygons); err != nil {
+			return item, fmt.Errorf("decode multipolygon: %w", err)
+		}
+	default:
+		return item, nil
+	}
+
+	item.bbox = [4]float64{math.MaxFloat64, math.MaxFloat64, -math.MaxFloat64, -math.MaxFloat64}
+
+	for _, polygon := range polygons {
+		rings := make([][][2]float64, 0, len(polygon))
+
+		for _, ring := range polygon {
+			points := make([][2]float64, 0, len(ring))
+
+			for _, point := range ring {
+				if len(point) < 2 {
+					return item, errors.New("point without longitude and latitude")
+				}
+
+				points = append(points, [2]float64{point[0], point[1]})
+
+				item.bbox[0] = min(item.bbox[0], point[0])
+				item.bbox[1] = min(item.bbox[1], point[1])
+				item.bbox[2] = max(item.bbox[2], point[0])
+				item.bbox[3] = max(item.bbox[3], point[1])
+			}
+
+			rings = append(rings, points)
+		}
+
+		item.polygons = append(item.polygons, rings)
+	}
+
+	item.address = make(map[string]string)
+
+	for key, value := range feature.Properties {
+		if _, ok := timezoneKeys[key]; ok {
+			item.timezone, _ = value.(string)
+			continue
+		}
+
+		addressKey, ok := boundaryKeys[key]
+		if !ok {
+			continue
+		}
+
+		if content, ok := value.(string); ok {
+			if addressKey == countryCode {
+				content = strings.ToLower(content)
+			}
+
+			setAddress(item.address, addressKey, content)
+		}
+	}
+
+	return item, nil
+}
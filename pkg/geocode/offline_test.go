@@ -0,0 +1,159 @@
+package geocode
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ViBiOh/exas/pkg/model"
+)
+
+const (
+	geonamesCities = "2988507\tParis\tParis\t\t48.85341\t2.3488\tP\tPPLC\tFR\t\t11\t75\t751\t75056\t2138551\t\t42\tEurope/Paris\t2024-01-01\n" +
+		"3031582\tBoulogne-Billancourt\tBoulogne-Billancourt\t\t48.83545\t2.24128\tP\tPPL\tFR\t\t11\t92\t921\t92012\t121334\t\t40\tEurope/Paris\t2024-01-01\n" +
+		"2892794\tKarlsruhe\tKarlsruhe\t\t49.00937\t8.40444\tP\tPPLA2\tDE\t\t01\t082\t08212\t08212000\t283799\t\t115\tEurope/Berlin\t2024-01-01\n" +
+		"5128581\tNew York City\tNew York City\t\t40.71427\t-74.00597\tP\tPPL\tUS\t\tNY\t061\t\t\t8804190\t10\t57\tAmerica/New_York\t2024-01-01\n"
+
+	boundaries = `{"type":"FeatureCollection","features":[
+{"type":"Feature","properties":{"country":"France","ISO_A2":"FR"},"geometry":{"type":"Polygon","coordinates":[[[-5,42],[8,42],[8,51],[-5,51],[-5,42]]]}},
+{"type":"Feature","properties":{"state":"Île-de-France","name":"ignored"},"geometry":{"type":"MultiPolygon","coordinates":[[[[1.4,48.1],[3.6,48.1],[3.6,49.3],[1.4,49.3],[1.4,48.1]],[[2.0,48.5],[2.1,48.5],[2.1,48.6],[2.0,48.6],[2.0,48.5]]]]}}
+]}`
+)
+
+func TestOfflineReverse(t *testing.T) {
+	t.Parallel()
+
+	directory := t.TempDir()
+
+	citiesPath := filepath.Join(directory, "cities.txt")
+	if err := os.WriteFile(citiesPath, []byte(geonamesCities), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	boundariesPath := filepath.Join(directory, "boundaries.geojson")
+	if err := os.WriteFile(boundariesPath, []byte(boundaries), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	provider, err := newOffline([]string{citiesPath, boundariesPath})
+	if err != nil {
+		t.Fatalf("newOffline: %s", err)
+	}
+
+	type args struct {
+		latitude  float64
+		longitude float64
+	}
+
+	cases := map[string]struct {
+		args args
+		want map[string]string
+	}{
+		"eiffel tower": {
+			args{
+				latitude:  48.858370,
+				longitude: 2.294481,
+			},
+			map[string]string{"city": "Paris", "state": "Île-de-France", "country": "France", "country_code": "fr"},
+		},
+		"closer to boulogne": {
+			args{
+				latitude:  48.840,
+				longitude: 2.245,
+			},
+			map[string]string{"city": "Boulogne-Billancourt", "state": "Île-de-France", "country": "France", "country_code": "fr"},
+		},
+		"hole in state": {
+			args{
+				latitude:  48.55,
+				longitude: 2.05,
+			},
+			map[string]string{"city": "Boulogne-Billancourt", "country": "France", "country_code": "fr"},
+		},
+		"nearest across the border": {
+			args{
+				latitude:  49.0,
+				longitude: 7.95,
+			},
+			map[string]string{"country": "France", "country_code": "fr"},
+		},
+		"no boundary": {
+			args{
+				latitude:  40.7484,
+				longitude: -73.9857,
+			},
+			map[string]string{"city": "New York City", "country_code": "us"},
+		},
+		"nothing around": {
+			args{
+				latitude:  -45,
+				longitude: 170,
+			},
+			nil,
+		},
+	}
+
+	for intention, tc := range cases {
+		t.Run(intention, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := provider.Reverse(context.Background(), tc.args.latitude, tc.args.longitude, "")
+			if err != nil {
+				t.Fatalf("Reverse: %s", err)
+			}
+
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("Reverse() = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestOfflineSearch(t *testing.T) {
+	t.Parallel()
+
+	directory := t.TempDir()
+
+	citiesPath := filepath.Join(directory, "cities.txt")
+	if err := os.WriteFile(citiesPath, []byte(geonamesCities+
+		"4717560\tParis\tParis\t\t33.66094\t-95.55551\tP\tPPLA2\tUS\t\tTX\t277\t\t\t24782\t182\t180\tAmerica/Chicago\t2024-01-01\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	provider, err := newOffline([]string{citiesPath})
+	if err != nil {
+		t.Fatalf("newOffline: %s", err)
+	}
+
+	got, err := provider.Search(context.Background(), " paris ", 5, "")
+	if err != nil {
+		t.Fatalf("Search: %s", err)
+	}
+
+	want := []model.Geocode{
+		{Latitude: 48.85341, Longitude: 2.3488, Address: map[string]string{"city": "Paris", "country_code": "fr"}},
+		{Latitude: 33.66094, Longitude: -95.55551, Address: map[string]string{"city": "Paris", "country_code": "us"}},
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Search() = %v, want %v", got, want)
+	}
+}
+
+func TestNewOffline(t *testing.T) {
+	t.Parallel()
+
+	directory := t.TempDir()
+
+	invalidPath := filepath.Join(directory, "invalid.txt")
+	if err := os.WriteFile(invalidPath, []byte("Paris\t48.85341\t2.3488\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := newOffline([]string{invalidPath}); err == nil || !strings.Contains(err.Error(), "unhandled format of 3 columns") {
+		t.Errorf("newOffline() = %v, want unhandled format error", err)
+	}
+}
@@ -0,0 +1,127 @@
+package geocode
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/ViBiOh/exas/pkg/model"
+	"github.com/ViBiOh/httputils/v4/pkg/httpjson"
+	"github.com/ViBiOh/httputils/v4/pkg/request"
+)
+
+type peliasProperties struct {
+	HouseNumber   string `json:"housenumber"`
+	Street        string `json:"street"`
+	Neighbourhood string `json:"neighbourhood"`
+	Locality      string `json:"locality"`
+	County        string `json:"county"`
+	Region        string `json:"region"`
+	PostalCode    string `json:"postalcode"`
+	Country       string `json:"country"`
+	CountryCode   string `json:"country_code"`
+}
+
+type peliasResponse struct {
+	Features []struct {
+		Properties peliasProperties `json:"properties"`
+		Geometry   struct {
+			Coordinates []float64 `json:"coordinates"`
+		} `json:"geometry"`
+	} `json:"features"`
+}
+
+// pelias is the geocoder of the Pelias project, also served by geocode.earth, https://github.com/pelias/pelias
+type pelias struct {
+	req    request.Request
+	apiKey string
+}
+
+func (p pelias) Reverse(ctx context.Context, latitude, longitude float64, language string) (map[string]string, error) {
+	params := url.Values{}
+	params.Add("point.lat", fmt.Sprintf("%.6f", latitude))
+	params.Add("point.lon", fmt.Sprintf("%.6f", longitude))
+	params.Add("size", "1")
+
+	if len(language) != 0 {
+		params.Add("lang", firstLanguage(language))
+	}
+
+	if len(p.apiKey) != 0 {
+		params.Add("api_key", p.apiKey)
+	}
+
+	resp, err := p.req.Path("/v1/reverse?%s", params.Encode()).Send(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("get reverse geocoding: %w", err)
+	}
+
+	reverseGeo, err := httpjson.Read[peliasResponse](resp)
+	if err != nil {
+		return nil, fmt.Errorf("decode reverse geocoding: %w: %w", errDecode, err)
+	}
+
+	if len(reverseGeo.Features) == 0 {
+		return nil, nil
+	}
+
+	return reverseGeo.Features[0].Properties.address(), nil
+}
+
+func (p peliasProperties) address() map[string]string {
+	address := make(map[string]string)
+
+	setAddress(address, "house_number", p.HouseNumber)
+	setAddress(address, "road", p.Street)
+	setAddress(address, "suburb", p.Neighbourhood)
+	setAddress(address, "city", p.Locality)
+	setAddress(address, "county", p.County)
+	setAddress(address, "state", p.Region)
+	setAddress(address, "postcode", p.PostalCode)
+	setAddress(address, "country", p.Country)
+	setAddress(address, "country_code", strings.ToLower(p.CountryCode))
+
+	return address
+}
+
+func (p pelias) Search(ctx context.Context, query string, limit int, language string) ([]model.Geocode, error) {
+	params := url.Values{}
+	params.Add("text", query)
+	params.Add("size", strconv.Itoa(limit))
+
+	if len(language) != 0 {
+		params.Add("lang", firstLanguage(language))
+	}
+
+	if len(p.apiKey) != 0 {
+		params.Add("api_key", p.apiKey)
+	}
+
+	resp, err := p.req.Path("/v1/search?%s", params.Encode()).Send(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("search: %w", err)
+	}
+
+	places, err := httpjson.Read[peliasResponse](resp)
+	if err != nil {
+		return nil, fmt.Errorf("decode search: %w: %w", errDecode, err)
+	}
+
+	output := make([]model.Geocode, 0, len(places.Features))
+
+	for _, feature := range places.Features {
+		if len(feature.Geometry.Coordinates) < 2 {
+			continue
+		}
+
+		output = append(output, model.Geocode{
+			Address:   feature.Properties.address(),
+			Latitude:  feature.Geometry.Coordinates[1],
+			Longitude: feature.Geometry.Coordinates[0],
+		})
+	}
+
+	return output, nil
+}
@@ -0,0 +1,118 @@
+package geocode
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/ViBiOh/exas/pkg/model"
+	"github.com/ViBiOh/httputils/v4/pkg/httpjson"
+	"github.com/ViBiOh/httputils/v4/pkg/request"
+)
+
+type photonProperties struct {
+	HouseNumber string `json:"housenumber"`
+	Street      string `json:"street"`
+	District    string `json:"district"`
+	City        string `json:"city"`
+	County      string `json:"county"`
+	State       string `json:"state"`
+	Postcode    string `json:"postcode"`
+	Country     string `json:"country"`
+	CountryCode string `json:"countrycode"`
+}
+
+type photonResponse struct {
+	Features []struct {
+		Properties photonProperties `json:"properties"`
+		Geometry   struct {
+			Coordinates []float64 `json:"coordinates"`
+		} `json:"geometry"`
+	} `json:"features"`
+}
+
+// photon is the geocoder of komoot, built on OpenStreetMap data, https://github.com/komoot/photon
+type photon struct {
+	req request.Request
+}
+
+func (p photon) Reverse(ctx context.Context, latitude, longitude float64, language string) (map[string]string, error) {
+	params := url.Values{}
+	params.Add("lat", fmt.Sprintf("%.6f", latitude))
+	params.Add("lon", fmt.Sprintf("%.6f", longitude))
+	params.Add("limit", "1")
+
+	if len(language) != 0 {
+		params.Add("lang", primaryLanguage(language))
+	}
+
+	resp, err := p.req.Path("/reverse?%s", params.Encode()).Send(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("get reverse geocoding: %w", err)
+	}
+
+	reverseGeo, err := httpjson.Read[photonResponse](resp)
+	if err != nil {
+		return nil, fmt.Errorf("decode reverse geocoding: %w: %w", errDecode, err)
+	}
+
+	if len(reverseGeo.Features) == 0 {
+		return nil, nil
+	}
+
+	return reverseGeo.Features[0].Properties.address(), nil
+}
+
+func (p photonProperties) address() map[string]string {
+	address := make(map[string]string)
+
+	setAddress(address, "house_number", p.HouseNumber)
+	setAddress(address, "road", p.Street)
+	setAddress(address, "suburb", p.District)
+	setAddress(address, "city", p.City)
+	setAddress(address, "county", p.County)
+	setAddress(address, "state", p.State)
+	setAddress(address, "postcode", p.Postcode)
+	setAddress(address, "country", p.Country)
+	setAddress(address, "country_code", strings.ToLower(p.CountryCode))
+
+	return address
+}
+
+func (p photon) Search(ctx context.Context, query string, limit int, language string) ([]model.Geocode, error) {
+	params := url.Values{}
+	params.Add("q", query)
+	params.Add("limit", strconv.Itoa(limit))
+
+	if len(language) != 0 {
+		params.Add("lang", primaryLanguage(language))
+	}
+
+	resp, err := p.req.Path("/api?%s", params.Encode()).Send(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("search: %w", err)
+	}
+
+	places, err := httpjson.Read[photonResponse](resp)
+	if err != nil {
+		return nil, fmt.Errorf("decode search: %w: %w", errDecode, err)
+	}
+
+	output := make([]model.Geocode, 0, len(places.Features))
+
+	for _, feature := range places.Features {
+		if len(feature.Geometry.Coordinates) < 2 {
+			continue
+		}
+
+		output = append(output, model.Geocode{
+			Address:   feature.Properties.address(),
+			Latitude:  feature.Geometry.Coordinates[1],
+			Longitude: feature.Geometry.Coordinates[0],
+		})
+	}
+
+	return output, nil
+}
@@ -0,0 +1,214 @@
+package geocode
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"sync"
+	"time"
+
+	absto "github.com/ViBiOh/absto/pkg/model"
+	"go.yaml.in/yaml/v3"
+)
+
+// placeDefinition is a named circle, with a center and a radius in meters, or polygon, with [latitude, longitude] vertices
+type placeDefinition struct {
+	Name      string       `yaml:"name"`
+	Polygon   [][2]float64 `yaml:"polygon"`
+	Latitude  float64      `yaml:"lat"`
+	Longitude float64      `yaml:"lon"`
+	Radius    float64      `yaml:"radius"`
+	Private   bool         `yaml:"private"`
+}
+
+type knownPlace struct {
+	name      string
+	area      *area
+	latitude  float64
+	longitude float64
+	radius    float64
+	private   bool
+}
+
+func (p knownPlace) contains(latitude, longitude float64) bool {
+	if p.area != nil {
+		return p.area.contains(latitude, longitude)
+	}
+
+	return haversine(latitude, longitude, p.latitude, p.longitude) <= p.radius
+}
+
+var errPlacesNotLoaded = errors.New("places not loaded yet")
+
+// places are the known places of a storage file, reloaded when changed. The error of the last load makes them unavailable.
+type places struct {
+	loaded   time.Time
+	modified time.Time
+	storage  absto.Storage
+	err      error
+	pathname string
+	items    []knownPlace
+	mutex    sync.RWMutex
+	refresh  sync.Mutex
+}
+
+func newPlaces(config *Config, storage absto.Storage) (*places, error) {
+	if len(config.GeocodePlacesFile) == 0 {
+		return nil, nil
+	}
+
+	if storage == nil || !storage.Enabled() {
+		return nil, fmt.Errorf("places in `%s` require a storage", config.GeocodePlacesFile)
+	}
+
+	return &places{
+		storage:  storage,
+		pathname: config.GeocodePlacesFile,
+		err:      errPlacesNotLoaded,
+	}, nil
+}
+
+// match returns the labels of the places containing the coordinates, and if one of them is private.
+// An error is returned while places are unavailable, as a private place could be missed.
+func (p *places) match(ctx context.Context, latitude, longitude float64) (labels []string, private bool, err error) {
+	p.load(ctx)
+
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+
+	if p.err != nil {
+		return nil, false, p.err
+	}
+
+	for _, item := range p.items {
+		if item.contains(latitude, longitude) {
+			labels = append(labels, item.name)
+			private = private || item.private
+		}
+	}
+
+	return labels, private, nil
+}
+
+// load reads the file at most once per refresh interval, parsing it only when modified
+func (p *places) load(ctx context.Context) {
+	p.refresh.Lock()
+	defer p.refresh.Unlock()
+
+	if time.Since(p.loaded) < refreshInterval {
+		return
+	}
+
+	p.loaded = time.Now()
+
+	info, err := p.storage.Stat(ctx, p.pathname)
+	if err != nil {
+		p.fail(ctx, fmt.Errorf("stat: %w", err))
+		return
+	}
+
+	if info.Date.Equal(p.modified) && p.available() {
+		return
+	}
+
+	items, err := p.parse(ctx)
+	if err != nil {
+		p.fail(ctx, fmt.Errorf("parse: %w", err))
+		return
+	}
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	p.modified = info.Date
+	p.items = items
+	p.err = nil
+}
+
+func (p *places) available() bool {
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+
+	return p.err == nil
+}
+
+func (p *places) fail(ctx context.Context, err error) {
+	slog.LogAttrs(ctx, slog.LevelError, "load places", slog.String("item", p.pathname), slog.Any("error", err))
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	p.err = fmt.Errorf("load places: %w", err)
+}
+
+func (p *places) parse(ctx context.Context) ([]knownPlace, error) {
+	reader, err := p.storage.ReadFrom(ctx, p.pathname)
+	if err != nil {
+		return nil, fmt.Errorf("read: %w", err)
+	}
+
+	defer func() {
+		if closeErr := reader.Close(); closeErr != nil {
+			slog.LogAttrs(ctx, slog.LevelError, "close places", slog.String("item", p.pathname), slog.Any("error", closeErr))
+		}
+	}()
+
+	return parsePlaces(reader)
+}
+
+// parsePlaces decodes a YAML list of places, JSON being valid YAML
+func parsePlaces(reader io.Reader) ([]knownPlace, error) {
+	var definitions []placeDefinition
+
+	if err := yaml.NewDecoder(reader).Decode(&definitions); err != nil && err != io.EOF {
+		return nil, fmt.Errorf("decode: %w", err)
+	}
+
+	output := make([]knownPlace, 0, len(definitions))
+
+	for index, definition := range definitions {
+		if len(definition.Name) == 0 {
+			return nil, fmt.Errorf("place #%d has no name", index+1)
+		}
+
+		item := knownPlace{
+			name:    definition.Name,
+			private: definition.Private,
+		}
+
+		switch {
+		case len(definition.Polygon) >= 3:
+			item.area = polygonArea(definition.Polygon)
+		case definition.Radius > 0:
+			item.latitude = definition.Latitude
+			item.longitude = definition.Longitude
+			item.radius = definition.Radius
+		default:
+			return nil, fmt.Errorf("place `%s` needs a polygon of at least 3 vertices or a radius", definition.Name)
+		}
+
+		output = append(output, item)
+	}
+
+	return output, nil
+}
+
+// polygonArea converts [latitude, longitude] vertices to the [longitude, latitude] ring of an area
+func polygonArea(vertices [][2]float64) *area {
+	ring := make([][2]float64, len(vertices))
+	bbox := [4]float64{math.MaxFloat64, math.MaxFloat64, -math.MaxFloat64, -math.MaxFloat64}
+
+	for index, vertex := range vertices {
+		ring[index] = [2]float64{vertex[1], vertex[0]}
+
+		bbox[0] = min(bbox[0], vertex[1])
+		bbox[1] = min(bbox[1], vertex[0])
+		bbox[2] = max(bbox[2], vertex[1])
+		bbox[3] = max(bbox[3], vertex[0])
+	}
+
+	return &area{polygons: [][][][2]float64{{ring}}, bbox: bbox}
+}
@@ -0,0 +1,158 @@
+package geocode
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ViBiOh/absto/pkg/filesystem"
+	"github.com/ViBiOh/exas/pkg/model"
+)
+
+const knownPlaces = `
+- name: Home
+  lat: 48.8584
+  lon: 2.2945
+  radius: 200
+  private: true
+- name: Park
+  polygon: [[48.85, 2.28], [48.85, 2.31], [48.87, 2.31], [48.87, 2.28]]
+`
+
+func TestPlacesMatch(t *testing.T) {
+	t.Parallel()
+
+	items, err := parsePlaces(strings.NewReader(knownPlaces))
+	if err != nil {
+		t.Fatalf("parsePlaces: %s", err)
+	}
+
+	instance := &places{items: items, loaded: time.Now()}
+
+	type args struct {
+		latitude  float64
+		longitude float64
+	}
+
+	cases := map[string]struct {
+		args        args
+		want        []string
+		wantPrivate bool
+	}{
+		"home": {
+			args{
+				latitude:  48.8590,
+				longitude: 2.2950,
+			},
+			[]string{"Home", "Park"},
+			true,
+		},
+		"park": {
+			args{
+				latitude:  48.8650,
+				longitude: 2.3050,
+			},
+			[]string{"Park"},
+			false,
+		},
+		"outside": {
+			args{
+				latitude:  48.8800,
+				longitude: 2.3050,
+			},
+			nil,
+			false,
+		},
+	}
+
+	for intention, tc := range cases {
+		t.Run(intention, func(t *testing.T) {
+			t.Parallel()
+
+			got, gotPrivate, gotErr := instance.match(context.Background(), tc.args.latitude, tc.args.longitude)
+
+			if gotErr != nil {
+				t.Errorf("match() error = %v", gotErr)
+			} else if gotPrivate != tc.wantPrivate {
+				t.Errorf("match() private = %t, want %t", gotPrivate, tc.wantPrivate)
+			} else if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("match() = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestResolvePlacesUnavailable(t *testing.T) {
+	t.Parallel()
+
+	directory := t.TempDir()
+
+	if err := os.WriteFile(filepath.Join(directory, "invalid.yaml"), []byte("- lat: 48.8584"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	storage, err := filesystem.New(directory)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	type args struct {
+		pathname string
+	}
+
+	cases := map[string]struct {
+		args    args
+		wantErr bool
+	}{
+		"valid": {
+			args{
+				pathname: "/valid.yaml",
+			},
+			false,
+		},
+		"missing": {
+			args{
+				pathname: "/missing.yaml",
+			},
+			true,
+		},
+		"invalid": {
+			args{
+				pathname: "/invalid.yaml",
+			},
+			true,
+		},
+	}
+
+	if err := os.WriteFile(filepath.Join(directory, "valid.yaml"), []byte(knownPlaces), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	for intention, tc := range cases {
+		t.Run(intention, func(t *testing.T) {
+			t.Parallel()
+
+			instance, err := newPlaces(&Config{GeocodePlacesFile: tc.args.pathname}, storage)
+			if err != nil {
+				t.Fatalf("newPlaces: %s", err)
+			}
+
+			provider := &stubProvider{}
+			service := Service{provider: provider, places: instance}
+
+			_, gotErr := service.Resolve(context.Background(), model.Geocode{Latitude: 48.8650, Longitude: 2.3050}, "")
+
+			if (gotErr != nil) != tc.wantErr {
+				t.Errorf("Resolve() error = %v, want error %t", gotErr, tc.wantErr)
+			}
+
+			if got, want := provider.calls.Load() > 0, !tc.wantErr; got != want {
+				t.Errorf("Resolve() provider called = %t, want %t", got, want)
+			}
+		})
+	}
+}
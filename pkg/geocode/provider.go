@@ -0,0 +1,76 @@
+package geocode
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/ViBiOh/exas/pkg/model"
+	"github.com/ViBiOh/httputils/v4/pkg/request"
+)
+
+const (
+	providerNominatim = "nominatim"
+	providerPhoton    = "photon"
+	providerPelias    = "pelias"
+	providerOffline   = "offline"
+
+	userAgent = "fibr, reverse geocoding from exif data"
+)
+
+var (
+	errDecode = errors.New("decode")
+
+	// ErrProvider wraps the failures of the geocoding provider, e.g. unreachable API or unexpected response
+	ErrProvider = errors.New("geocoding provider")
+)
+
+// Retryable reports if a failure of the provider may succeed later: network errors, server errors and rate limiting.
+// Undecodable or refused requests, and ErrLimited of the local rate limit, are not.
+func Retryable(err error) bool {
+	if !errors.Is(err, ErrProvider) || errors.Is(err, errDecode) {
+		return false
+	}
+
+	if responseErr, ok := errors.AsType[request.Error](err); ok {
+		return responseErr.StatusCode >= http.StatusInternalServerError || responseErr.StatusCode == http.StatusTooManyRequests
+	}
+
+	return true
+}
+
+// Provider resolves coordinates to an address and searches places by name, with the address keys of Nominatim (e.g. `road`, `city`, `postcode`, `country_code`)
+type Provider interface {
+	Reverse(ctx context.Context, latitude, longitude float64, language string) (map[string]string, error)
+	Search(ctx context.Context, query string, limit int, language string) ([]model.Geocode, error)
+}
+
+func newProvider(config *Config, zoom int) (Provider, error) {
+	if config.GeocodeProvider == providerOffline {
+		return newOffline(config.GeocodeDatasets)
+	}
+
+	if len(config.GeocodeURL) == 0 {
+		return nil, nil
+	}
+
+	req := request.New().Header("User-Agent", userAgent).Get(config.GeocodeURL)
+
+	switch config.GeocodeProvider {
+	case providerNominatim, "":
+		return nominatim{req: req, zoom: zoom}, nil
+	case providerPhoton:
+		return photon{req: req}, nil
+	case providerPelias:
+		return pelias{req: req, apiKey: config.GeocodeAPIKey}, nil
+	default:
+		return nil, fmt.Errorf("unknown provider `%s`", config.GeocodeProvider)
+	}
+}
+
+func setAddress(address map[string]string, key, value string) {
+	if len(value) != 0 {
+		address[key] = value
+	}
+}
@@ -0,0 +1,161 @@
+package geocode
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/ViBiOh/exas/pkg/model"
+)
+
+func TestProviderReverse(t *testing.T) {
+	t.Parallel()
+
+	type args struct {
+		provider string
+		payload  string
+	}
+
+	cases := map[string]struct {
+		args     args
+		wantPath string
+		want     map[string]string
+	}{
+		"nominatim": {
+			args{
+				provider: providerNominatim,
+				payload:  `{"address":{"road":"Avenue Gustave Eiffel","city":"Paris","postcode":"75007","country":"France","country_code":"fr"}}`,
+			},
+			"/reverse",
+			map[string]string{"road": "Avenue Gustave Eiffel", "city": "Paris", "postcode": "75007", "country": "France", "country_code": "fr"},
+		},
+		"photon": {
+			args{
+				provider: providerPhoton,
+				payload:  `{"features":[{"properties":{"street":"Avenue Gustave Eiffel","city":"Paris","postcode":"75007","country":"France","countrycode":"FR"}}]}`,
+			},
+			"/reverse",
+			map[string]string{"road": "Avenue Gustave Eiffel", "city": "Paris", "postcode": "75007", "country": "France", "country_code": "fr"},
+		},
+		"pelias": {
+			args{
+				provider: providerPelias,
+				payload:  `{"features":[{"properties":{"street":"Avenue Gustave Eiffel","locality":"Paris","region":"Paris","postalcode":"75007","country":"France","country_code":"FR"}}]}`,
+			},
+			"/v1/reverse",
+			map[string]string{"road": "Avenue Gustave Eiffel", "city": "Paris", "state": "Paris", "postcode": "75007", "country": "France", "country_code": "fr"},
+		},
+		"no result": {
+			args{
+				provider: providerPhoton,
+				payload:  `{"features":[]}`,
+			},
+			"/reverse",
+			nil,
+		},
+	}
+
+	for intention, tc := range cases {
+		t.Run(intention, func(t *testing.T) {
+			t.Parallel()
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != tc.wantPath {
+					w.WriteHeader(http.StatusNotFound)
+					return
+				}
+
+				w.Header().Set("Content-Type", "application/json")
+				_, _ = w.Write([]byte(tc.args.payload))
+			}))
+			defer server.Close()
+
+			provider, err := newProvider(&Config{GeocodeProvider: tc.args.provider, GeocodeURL: server.URL}, maxZoom)
+			if err != nil {
+				t.Fatalf("newProvider: %s", err)
+			}
+
+			got, err := provider.Reverse(context.Background(), 48.858370, 2.294481, "")
+			if err != nil {
+				t.Fatalf("Reverse: %s", err)
+			}
+
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("Reverse() = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestProviderSearch(t *testing.T) {
+	t.Parallel()
+
+	type args struct {
+		provider string
+		payload  string
+	}
+
+	cases := map[string]struct {
+		args     args
+		wantPath string
+		want     []model.Geocode
+	}{
+		"nominatim": {
+			args{
+				provider: providerNominatim,
+				payload:  `[{"lat":"38.7077507","lon":"-9.1365919","address":{"city":"Lisboa","country_code":"pt"}}]`,
+			},
+			"/search",
+			[]model.Geocode{{Latitude: 38.7077507, Longitude: -9.1365919, Address: map[string]string{"city": "Lisboa", "country_code": "pt"}}},
+		},
+		"photon": {
+			args{
+				provider: providerPhoton,
+				payload:  `{"features":[{"geometry":{"coordinates":[-9.1365919,38.7077507]},"properties":{"city":"Lisboa","countrycode":"PT"}}]}`,
+			},
+			"/api",
+			[]model.Geocode{{Latitude: 38.7077507, Longitude: -9.1365919, Address: map[string]string{"city": "Lisboa", "country_code": "pt"}}},
+		},
+		"pelias": {
+			args{
+				provider: providerPelias,
+				payload:  `{"features":[{"geometry":{"coordinates":[-9.1365919,38.7077507]},"properties":{"locality":"Lisboa","country_code":"PT"}}]}`,
+			},
+			"/v1/search",
+			[]model.Geocode{{Latitude: 38.7077507, Longitude: -9.1365919, Address: map[string]string{"city": "Lisboa", "country_code": "pt"}}},
+		},
+	}
+
+	for intention, tc := range cases {
+		t.Run(intention, func(t *testing.T) {
+			t.Parallel()
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != tc.wantPath {
+					w.WriteHeader(http.StatusNotFound)
+					return
+				}
+
+				w.Header().Set("Content-Type", "application/json")
+				_, _ = w.Write([]byte(tc.args.payload))
+			}))
+			defer server.Close()
+
+			provider, err := newProvider(&Config{GeocodeProvider: tc.args.provider, GeocodeURL: server.URL}, maxZoom)
+			if err != nil {
+				t.Fatalf("newProvider: %s", err)
+			}
+
+			got, err := provider.Search(context.Background(), "Lisbon", 5, "")
+			if err != nil {
+				t.Fatalf("Search: %s", err)
+			}
+
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("Search() = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,53 @@
+package geocode
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/ViBiOh/exas/pkg/model"
+	"github.com/ViBiOh/httputils/v4/pkg/telemetry"
+)
+
+// Searchable reports if places can be searched by name, which requires a provider.
+func (s Service) Searchable() bool {
+	return s.provider != nil
+}
+
+// Search returns the places matching the query, under the same rate limit as the reverse geocoding. An empty language uses the configured one.
+func (s Service) Search(ctx context.Context, query string, limit int, language string) (candidates []model.Geocode, err error) {
+	ctx, end := telemetry.StartSpan(ctx, s.tracer, "search")
+	defer end(&err)
+
+	if err = s.wait(ctx); err != nil {
+		s.increaseMetric(ctx, "search", "skipped")
+		return nil, fmt.Errorf("rate limit: %w", err)
+	}
+
+	s.increaseMetric(ctx, "search", "requested")
+
+	candidates, err = s.provider.Search(ctx, query, limit, s.languageOrDefault(language))
+	if err != nil {
+		if errors.Is(err, errDecode) {
+			s.increaseMetric(ctx, "search", "decode_error")
+		} else {
+			s.increaseMetric(ctx, "search", "api_error")
+		}
+
+		return nil, fmt.Errorf("search: %w: %w", ErrProvider, err)
+	}
+
+	for index, candidate := range candidates {
+		candidate.Address = addressFilter{include: s.filter.include, exclude: s.filter.exclude}.apply(candidate.Address)
+		candidates[index] = s.Coarsen(candidate)
+	}
+
+	if len(candidates) == 0 {
+		s.increaseMetric(ctx, "search", "empty")
+		return []model.Geocode{}, nil
+	}
+
+	s.increaseMetric(ctx, "search", "success")
+
+	return candidates, nil
+}
@@ -0,0 +1,66 @@
+package geocode
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	_ "embed"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+	_ "time/tzdata" // zones are resolved without relying on the system database
+)
+
+const (
+	TimezoneSourceExif     = "exif"
+	TimezoneSourceGPS      = "gps"
+	TimezoneSourceFallback = "fallback"
+)
+
+// timezoneBoundaries are the land boundaries of timezone-boundary-builder (ODbL), as simplified by tzf-rel-lite, with coordinates rounded to about 100 meters
+//
+//go:embed timezones.geojson.gz
+var timezoneBoundaries []byte
+
+// embeddedTimezones is loaded once, the index being read-only afterward
+var embeddedTimezones = sync.OnceValues(func() (offline, error) {
+	reader, err := gzip.NewReader(bytes.NewReader(timezoneBoundaries))
+	if err != nil {
+		return offline{}, fmt.Errorf("gzip: %w", err)
+	}
+
+	output := offline{index: newIndex()}
+
+	return output, output.loadBoundaries(reader)
+})
+
+// Timezone returns the IANA location of the coordinates from the timezones of the offline datasets, with the TimezoneSourceGPS source.
+// A nil location is returned when none is found, an approximate zone would shift dates wrongly near borders.
+func (s Service) Timezone(ctx context.Context, latitude, longitude float64) (*time.Location, string) {
+	if s.timezones.index != nil {
+		if name := s.timezones.Timezone(latitude, longitude); len(name) != 0 {
+			location, err := time.LoadLocation(name)
+			if err == nil {
+				return location, TimezoneSourceGPS
+			}
+
+			slog.LogAttrs(ctx, slog.LevelWarn, "load timezone", slog.String("name", name), slog.Any("error", err))
+		}
+	}
+
+	return nil, ""
+}
+
+func newTimezones(config *Config) (offline, error) {
+	if len(config.GeocodeTimezoneDatasets) != 0 {
+		return newOffline(config.GeocodeTimezoneDatasets)
+	}
+
+	output, err := embeddedTimezones()
+	if err != nil {
+		return output, fmt.Errorf("embedded: %w", err)
+	}
+
+	return output, nil
+}
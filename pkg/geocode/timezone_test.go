@@ -0,0 +1,136 @@
+package geocode
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestTimezone(t *testing.T) {
+	t.Parallel()
+
+	citiesPath := filepath.Join(t.TempDir(), "cities.txt")
+	if err := os.WriteFile(citiesPath, []byte(geonamesCities), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	timezones, err := newOffline([]string{citiesPath})
+	if err != nil {
+		t.Fatalf("newOffline: %s", err)
+	}
+
+	instance := Service{timezones: timezones}
+
+	type args struct {
+		latitude  float64
+		longitude float64
+	}
+
+	cases := map[string]struct {
+		args       args
+		want       string
+		wantSource string
+	}{
+		"paris": {
+			args{
+				latitude:  48.858370,
+				longitude: 2.294481,
+			},
+			"Europe/Paris",
+			TimezoneSourceGPS,
+		},
+		"new york": {
+			args{
+				latitude:  40.7484,
+				longitude: -73.9857,
+			},
+			"America/New_York",
+			TimezoneSourceGPS,
+		},
+		"pacific": {
+			args{
+				latitude:  -45,
+				longitude: 170,
+			},
+			"",
+			"",
+		},
+	}
+
+	for intention, tc := range cases {
+		t.Run(intention, func(t *testing.T) {
+			t.Parallel()
+
+			got, gotSource := instance.Timezone(context.Background(), tc.args.latitude, tc.args.longitude)
+
+			if gotSource != tc.wantSource {
+				t.Errorf("Timezone() source = `%s`, want `%s`", gotSource, tc.wantSource)
+			} else if got == nil && len(tc.want) != 0 || got != nil && got.String() != tc.want {
+				t.Errorf("Timezone() = `%s`, want `%s`", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestNewTimezones(t *testing.T) {
+	t.Parallel()
+
+	timezones, err := newTimezones(&Config{})
+	if err != nil {
+		t.Fatalf("newTimezones: %s", err)
+	}
+
+	instance := Service{timezones: timezones}
+
+	type args struct {
+		latitude  float64
+		longitude float64
+	}
+
+	cases := map[string]struct {
+		args args
+		want string
+	}{
+		"paris": {
+			args{
+				latitude:  48.858370,
+				longitude: 2.294481,
+			},
+			"Europe/Paris",
+		},
+		"across the border": {
+			args{
+				latitude:  49.0069,
+				longitude: 8.4037,
+			},
+			"Europe/Berlin",
+		},
+		"antimeridian": {
+			args{
+				latitude:  64.7337,
+				longitude: 177.5089,
+			},
+			"Asia/Anadyr",
+		},
+		"ocean": {
+			args{
+				latitude:  0,
+				longitude: -140,
+			},
+			"",
+		},
+	}
+
+	for intention, tc := range cases {
+		t.Run(intention, func(t *testing.T) {
+			t.Parallel()
+
+			got, _ := instance.Timezone(context.Background(), tc.args.latitude, tc.args.longitude)
+
+			if got == nil && len(tc.want) != 0 || got != nil && got.String() != tc.want {
+				t.Errorf("Timezone() = `%s`, want `%s`", got, tc.want)
+			}
+		})
+	}
+}
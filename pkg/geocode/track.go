@@ -0,0 +1,332 @@
+package geocode
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"log/slog"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	absto "github.com/ViBiOh/absto/pkg/model"
+	"github.com/ViBiOh/exas/pkg/model"
+)
+
+type trackPoint struct {
+	time      time.Time
+	latitude  float64
+	longitude float64
+	altitude  float64
+}
+
+type trackFile struct {
+	date   time.Time
+	points []trackPoint
+}
+
+// tracks are the GPX and KML files of a storage directory, reloaded when changed
+type tracks struct {
+	loaded    time.Time
+	storage   absto.Storage
+	files     map[string]trackFile
+	directory string
+	points    []trackPoint
+	offset    time.Duration
+	maxGap    time.Duration
+	mutex     sync.RWMutex
+	refresh   sync.Mutex
+}
+
+func newTracks(config *Config, storage absto.Storage) (*tracks, error) {
+	if len(config.GeocodeTracksDirectory) == 0 {
+		return nil, nil
+	}
+
+	if storage == nil || !storage.Enabled() {
+		return nil, fmt.Errorf("tracks in `%s` require a storage", config.GeocodeTracksDirectory)
+	}
+
+	return &tracks{
+		storage:   storage,
+		directory: absto.Dirname(config.GeocodeTracksDirectory),
+		files:     make(map[string]trackFile),
+		offset:    config.GeocodeTracksOffset,
+		maxGap:    config.GeocodeTracksMaxGap,
+	}, nil
+}
+
+// Interpolate infers the position at the given date from the tracks, if the date is close enough to recorded points.
+// Dates without offset are shifted by the configured one, the others are compared by their instant.
+func (s Service) Interpolate(ctx context.Context, date time.Time, hasOffset bool) (model.Geocode, bool) {
+	if s.tracks == nil || date.IsZero() {
+		return model.Geocode{}, false
+	}
+
+	s.tracks.load(ctx)
+
+	return s.tracks.locate(date, hasOffset)
+}
+
+func (t *tracks) locate(date time.Time, hasOffset bool) (model.Geocode, bool) {
+	if !hasOffset {
+		date = date.Add(t.offset)
+	}
+
+	return t.interpolate(date.UTC())
+}
+
+func (t *tracks) interpolate(date time.Time) (model.Geocode, bool) {
+	t.mutex.RLock()
+	defer t.mutex.RUnlock()
+
+	index, _ := slices.BinarySearchFunc(t.points, date, func(point trackPoint, target time.Time) int {
+		return point.time.Compare(target)
+	})
+
+	var before, after *trackPoint
+
+	if index > 0 && date.Sub(t.points[index-1].time) <= t.maxGap {
+		before = &t.points[index-1]
+	}
+
+	if index < len(t.points) && t.points[index].time.Sub(date) <= t.maxGap {
+		after = &t.points[index]
+	}
+
+	switch {
+	case before != nil && after != nil:
+		ratio := 0.0
+		if span := after.time.Sub(before.time); span > 0 {
+			ratio = float64(date.Sub(before.time)) / float64(span)
+		}
+
+		return model.Geocode{
+			Latitude:  before.latitude + (after.latitude-before.latitude)*ratio,
+			Longitude: before.longitude + (after.longitude-before.longitude)*ratio,
+			Altitude:  before.altitude + (after.altitude-before.altitude)*ratio,
+			Inferred:  true,
+		}, true
+	case before != nil:
+		return before.geocode(), true
+	case after != nil:
+		return after.geocode(), true
+	default:
+		return model.Geocode{}, false
+	}
+}
+
+func (p trackPoint) geocode() model.Geocode {
+	return model.Geocode{
+		Latitude:  p.latitude,
+		Longitude: p.longitude,
+		Altitude:  p.altitude,
+		Inferred:  true,
+	}
+}
+
+// load walks the directory at most once per refresh interval, parsing only new or modified files.
+// Callers don't wait for a reload in progress, they use the current points, only swapped once parsed.
+func (t *tracks) load(ctx context.Context) {
+	if !t.refresh.TryLock() {
+		return
+	}
+	defer t.refresh.Unlock()
+
+	if time.Since(t.loaded) < refreshInterval {
+		return
+	}
+
+	t.loaded = time.Now()
+
+	files := make(map[string]trackFile, len(t.files))
+	changed := false
+
+	err := t.storage.Walk(ctx, t.directory, func(item absto.Item) error {
+		if item.IsDir() {
+			return nil
+		}
+
+		extension := strings.ToLower(item.Extension)
+		if extension != ".gpx" && extension != ".kml" {
+			return nil
+		}
+
+		if previous, ok := t.files[item.Pathname]; ok && previous.date.Equal(item.Date) {
+			files[item.Pathname] = previous
+			return nil
+		}
+
+		changed = true
+
+		points, err := t.parse(ctx, item.Pathname, extension)
+		if err != nil {
+			slog.LogAttrs(ctx, slog.LevelError, "parse track", slog.String("item", item.Pathname), slog.Any("error", err))
+		}
+
+		files[item.Pathname] = trackFile{date: item.Date, points: points}
+
+		return nil
+	})
+	if err != nil {
+		slog.LogAttrs(ctx, slog.LevelError, "walk tracks", slog.String("directory", t.directory), slog.Any("error", err))
+		return
+	}
+
+	if !changed && len(files) == len(t.files) {
+		return
+	}
+
+	var points []trackPoint
+	for _, file := range files {
+		points = append(points, file.points...)
+	}
+
+	slices.SortFunc(points, func(a, b trackPoint) int {
+		return a.time.Compare(b.time)
+	})
+
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	t.files = files
+	t.points = points
+}
+
+func (t *tracks) parse(ctx context.Context, pathname, extension string) ([]trackPoint, error) {
+	reader, err := t.storage.ReadFrom(ctx, pathname)
+	if err != nil {
+		return nil, fmt.Errorf("read: %w", err)
+	}
+
+	defer func() {
+		if closeErr := reader.Close(); closeErr != nil {
+			slog.LogAttrs(ctx, slog.LevelError, "close track", slog.String("item", pathname), slog.Any("error", closeErr))
+		}
+	}()
+
+	if extension == ".kml" {
+		return parseKML(reader)
+	}
+
+	return parseGPX(reader)
+}
+
+type gpxPoint struct {
+	Time      time.Time `xml:"time"`
+	Latitude  float64   `xml:"lat,attr"`
+	Longitude float64   `xml:"lon,attr"`
+	Elevation float64   `xml:"ele"`
+}
+
+type gpx struct {
+	Tracks []struct {
+		Segments []struct {
+			Points []gpxPoint `xml:"trkpt"`
+		} `xml:"trkseg"`
+	} `xml:"trk"`
+}
+
+func parseGPX(reader io.Reader) ([]trackPoint, error) {
+	var content gpx
+	if err := xml.NewDecoder(reader).Decode(&content); err != nil {
+		return nil, fmt.Errorf("decode gpx: %w", err)
+	}
+
+	var output []trackPoint
+
+	for _, track := range content.Tracks {
+		for _, segment := range track.Segments {
+			for _, point := range segment.Points {
+				if point.Time.IsZero() {
+					continue
+				}
+
+				output = append(output, trackPoint{
+					time:      point.Time,
+					latitude:  point.Latitude,
+					longitude: point.Longitude,
+					altitude:  point.Elevation,
+				})
+			}
+		}
+	}
+
+	return output, nil
+}
+
+// kmlTrack is a `gx:Track` extension, where `when` and `coord` are paired by position
+type kmlTrack struct {
+	When   []time.Time `xml:"when"`
+	Coords []string    `xml:"coord"`
+}
+
+func parseKML(reader io.Reader) ([]trackPoint, error) {
+	decoder := xml.NewDecoder(reader)
+
+	var output []trackPoint
+
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("decode kml: %w", err)
+		}
+
+		start, ok := token.(xml.StartElement)
+		if !ok || start.Name.Local != "Track" {
+			continue
+		}
+
+		var track kmlTrack
+		if err = decoder.DecodeElement(&track, &start); err != nil {
+			return nil, fmt.Errorf("decode track: %w", err)
+		}
+
+		for index := range min(len(track.When), len(track.Coords)) {
+			point, err := parseKMLCoord(track.Coords[index])
+			if err != nil {
+				return nil, fmt.Errorf("coord #%d: %w", index, err)
+			}
+
+			point.time = track.When[index]
+			output = append(output, point)
+		}
+	}
+
+	slices.SortFunc(output, func(a, b trackPoint) int {
+		return a.time.Compare(b.time)
+	})
+
+	return output, nil
+}
+
+// parseKMLCoord handles the `longitude latitude altitude` format of `gx:coord`
+func parseKMLCoord(value string) (point trackPoint, err error) {
+	parts := strings.Fields(value)
+	if len(parts) < 2 {
+		return point, fmt.Errorf("invalid coord `%s`", value)
+	}
+
+	if point.longitude, err = strconv.ParseFloat(parts[0], 64); err != nil {
+		return point, fmt.Errorf("parse longitude: %w", err)
+	}
+
+	if point.latitude, err = strconv.ParseFloat(parts[1], 64); err != nil {
+		return point, fmt.Errorf("parse latitude: %w", err)
+	}
+
+	if len(parts) > 2 {
+		if point.altitude, err = strconv.ParseFloat(parts[2], 64); err != nil {
+			return point, fmt.Errorf("parse altitude: %w", err)
+		}
+	}
+
+	return point, nil
+}
@@ -0,0 +1,174 @@
+package geocode
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ViBiOh/exas/pkg/model"
+)
+
+const (
+	gpxContent = `<?xml version="1.0" encoding="UTF-8"?>
+<gpx version="1.1" xmlns="http://www.topografix.com/GPX/1/1"><trk><trkseg>
+<trkpt lat="48.0" lon="2.0"><ele>100</ele><time>2024-06-01T10:00:00Z</time></trkpt>
+<trkpt lat="49.0" lon="3.0"><ele>200</ele><time>2024-06-01T10:10:00Z</time></trkpt>
+</trkseg></trk></gpx>`
+
+	kmlContent = `<?xml version="1.0" encoding="UTF-8"?>
+<kml xmlns="http://www.opengis.net/kml/2.2" xmlns:gx="http://www.google.com/kml/ext/2.2"><Document><Placemark><gx:Track>
+<when>2024-06-01T10:10:00Z</when><when>2024-06-01T10:00:00Z</when>
+<gx:coord>3.0 49.0 200</gx:coord><gx:coord>2.0 48.0 100</gx:coord>
+</gx:Track></Placemark></Document></kml>`
+)
+
+func TestTracksInterpolate(t *testing.T) {
+	t.Parallel()
+
+	gpxPoints, err := parseGPX(strings.NewReader(gpxContent))
+	if err != nil {
+		t.Fatalf("parseGPX: %s", err)
+	}
+
+	kmlPoints, err := parseKML(strings.NewReader(kmlContent))
+	if err != nil {
+		t.Fatalf("parseKML: %s", err)
+	}
+
+	if !reflect.DeepEqual(gpxPoints, kmlPoints) {
+		t.Fatalf("gpx and kml points differ: %+v != %+v", gpxPoints, kmlPoints)
+	}
+
+	instance := &tracks{points: gpxPoints, maxGap: 10 * time.Minute}
+
+	cases := map[string]struct {
+		args   time.Time
+		want   model.Geocode
+		wantOk bool
+	}{
+		"between": {
+			time.Date(2024, 6, 1, 10, 5, 0, 0, time.UTC),
+			model.Geocode{Latitude: 48.5, Longitude: 2.5, Altitude: 150, Inferred: true},
+			true,
+		},
+		"after last": {
+			time.Date(2024, 6, 1, 10, 15, 0, 0, time.UTC),
+			model.Geocode{Latitude: 49, Longitude: 3, Altitude: 200, Inferred: true},
+			true,
+		},
+		"too far": {
+			time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC),
+			model.Geocode{},
+			false,
+		},
+	}
+
+	for intention, tc := range cases {
+		t.Run(intention, func(t *testing.T) {
+			t.Parallel()
+
+			got, gotOk := instance.interpolate(tc.args)
+
+			if gotOk != tc.wantOk {
+				t.Errorf("interpolate() ok = %t, want %t", gotOk, tc.wantOk)
+			} else if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("interpolate() = %+v, want %+v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestTracksLocate(t *testing.T) {
+	t.Parallel()
+
+	points, err := parseGPX(strings.NewReader(gpxContent))
+	if err != nil {
+		t.Fatalf("parseGPX: %s", err)
+	}
+
+	// camera clock on UTC+2 without timezone
+	instance := &tracks{points: points, offset: -2 * time.Hour, maxGap: 10 * time.Minute}
+
+	type args struct {
+		date      time.Time
+		hasOffset bool
+	}
+
+	cases := map[string]struct {
+		args   args
+		want   model.Geocode
+		wantOk bool
+	}{
+		"naive date shifted": {
+			args{
+				date: time.Date(2024, 6, 1, 12, 5, 0, 0, time.UTC),
+			},
+			model.Geocode{Latitude: 48.5, Longitude: 2.5, Altitude: 150, Inferred: true},
+			true,
+		},
+		"date with offset not shifted": {
+			args{
+				date:      time.Date(2024, 6, 1, 12, 5, 0, 0, time.FixedZone("", 2*60*60)),
+				hasOffset: true,
+			},
+			model.Geocode{Latitude: 48.5, Longitude: 2.5, Altitude: 150, Inferred: true},
+			true,
+		},
+		"utc date not shifted": {
+			args{
+				date:      time.Date(2024, 6, 1, 12, 5, 0, 0, time.UTC),
+				hasOffset: true,
+			},
+			model.Geocode{},
+			false,
+		},
+	}
+
+	for intention, tc := range cases {
+		t.Run(intention, func(t *testing.T) {
+			t.Parallel()
+
+			got, gotOk := instance.locate(tc.args.date, tc.args.hasOffset)
+
+			if gotOk != tc.wantOk {
+				t.Errorf("locate() ok = %t, want %t", gotOk, tc.wantOk)
+			} else if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("locate() = %+v, want %+v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestTracksLoadInProgress(t *testing.T) {
+	t.Parallel()
+
+	points, err := parseGPX(strings.NewReader(gpxContent))
+	if err != nil {
+		t.Fatalf("parseGPX: %s", err)
+	}
+
+	instance := &tracks{points: points, maxGap: 10 * time.Minute}
+	service := Service{tracks: instance}
+
+	// a slow walk of the directory holds the refresh
+	instance.refresh.Lock()
+	defer instance.refresh.Unlock()
+
+	done := make(chan bool)
+
+	go func() {
+		_, ok := service.Interpolate(context.Background(), time.Date(2024, 6, 1, 10, 5, 0, 0, time.UTC), true)
+		done <- ok
+	}()
+
+	select {
+	case ok := <-done:
+		if !ok {
+			t.Error("Interpolate() = false, want current points during reload")
+		}
+	case <-time.After(time.Second):
+		t.Error("Interpolate() waits for the reload")
+	}
+}
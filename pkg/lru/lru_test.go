@@ -0,0 +1,30 @@
+package lru
+
+import "testing"
+
+func TestCache(t *testing.T) {
+	t.Parallel()
+
+	cache := New[string, int](2)
+
+	cache.Set("first", 1)
+	cache.Set("second", 2)
+
+	if _, ok := cache.Get("first"); !ok {
+		t.Error("Get(first) = false, want true")
+	}
+
+	cache.Set("third", 3)
+
+	if _, ok := cache.Get("second"); ok {
+		t.Error("Get(second) = true, want evicted")
+	}
+
+	if got, ok := cache.Get("first"); !ok || got != 1 {
+		t.Errorf("Get(first) = (%d, %t), want (1, true)", got, ok)
+	}
+
+	if got := cache.Len(); got != 2 {
+		t.Errorf("Len() = %d, want 2", got)
+	}
+}
@@ -2,10 +2,22 @@ package model
 
 import "time"
 
+// Error is the body of failed HTTP requests, the code being stable for machines
+type Error struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
 type Exif struct {
-	Date    time.Time      `json:"date"`
-	Data    map[string]any `json:"data,omitempty"`
-	Geocode Geocode        `json:"geocode"`
+	Date           time.Time       `json:"date"`
+	Timezone       string          `json:"timezone,omitempty"`     // zone of the coordinates, when the date had no offset
+	OffsetSource   string          `json:"offsetSource,omitempty"` // exif, gps or fallback
+	DateSource     string          `json:"dateSource,omitempty"`   // tag the date was read from
+	DateOffset     string          `json:"dateOffset,omitempty"`   // offset of the date to UTC, e.g. +02:00
+	DateCandidates []DateCandidate `json:"dateCandidates,omitempty"`
+	Data           map[string]any  `json:"data,omitempty"`
+	Geocode        Geocode         `json:"geocode"`
+	Metadata       Metadata        `json:"metadata,omitzero"`
 }
 
 func (e Exif) IsZero() bool {
@@ -16,10 +28,78 @@ func (e Exif) HasData() bool {
 	return len(e.Data) != 0
 }
 
+// DateCandidate is a date tag of the file, with a zero Date when unparsable
+type DateCandidate struct {
+	Date time.Time `json:"date,omitzero"`
+	Tag  string    `json:"tag"`
+	Raw  string    `json:"raw"`
+}
+
+// Metadata is the normalized view of the raw Data, with values converted to base units.
+type Metadata struct {
+	MimeType    string     `json:"mimeType,omitempty"`
+	Camera      Camera     `json:"camera,omitzero"`
+	Lens        Lens       `json:"lens,omitzero"`
+	Keywords    []string   `json:"keywords,omitempty"`
+	Exposure    Exposure   `json:"exposure,omitzero"`
+	Dimensions  Dimensions `json:"dimensions,omitzero"`
+	Duration    float64    `json:"duration,omitempty"` // in seconds
+	Orientation int        `json:"orientation,omitempty"`
+	Rating      int        `json:"rating,omitempty"`
+	Video       Video      `json:"video,omitzero"`
+}
+
+// Video describes the streams of a video container
+type Video struct {
+	Track      Track   `json:"track,omitzero"`
+	VideoCodec string  `json:"videoCodec,omitempty"`
+	AudioCodec string  `json:"audioCodec,omitempty"`
+	FrameRate  float64 `json:"frameRate,omitempty"` // in frames per second
+	Rotation   int     `json:"rotation,omitempty"`  // in degrees, clockwise
+}
+
+// Track summarizes the GPS points embedded along a video
+type Track struct {
+	Start    time.Time  `json:"start,omitzero"`
+	End      time.Time  `json:"end,omitzero"`
+	Bounds   [4]float64 `json:"bounds,omitzero"` // minLat, minLon, maxLat, maxLon
+	Points   int        `json:"points,omitempty"`
+	Distance float64    `json:"distance,omitempty"` // in meters
+}
+
+type Camera struct {
+	Make  string `json:"make,omitempty"`
+	Model string `json:"model,omitempty"`
+}
+
+type Lens struct {
+	Make              string  `json:"make,omitempty"`
+	Model             string  `json:"model,omitempty"`
+	FocalLength       float64 `json:"focalLength,omitempty"`       // in millimeters
+	FocalLengthIn35mm float64 `json:"focalLengthIn35mm,omitempty"` // in millimeters
+}
+
+type Exposure struct {
+	Time         float64 `json:"time,omitempty"` // in seconds
+	FNumber      float64 `json:"fNumber,omitempty"`
+	Compensation float64 `json:"compensation,omitempty"` // in EV
+	ISO          int     `json:"iso,omitempty"`
+}
+
+type Dimensions struct {
+	Width  int `json:"width,omitempty"`
+	Height int `json:"height,omitempty"`
+}
+
 type Geocode struct {
 	Address   map[string]string `json:"address,omitempty"`
+	Labels    []string          `json:"labels,omitempty"` // names of the known places containing the coordinates
 	Latitude  float64           `json:"lat,omitempty"`
 	Longitude float64           `json:"lon,omitempty"`
+	Altitude  float64           `json:"altitude,omitempty"` // in meters, negative below sea level
+	Bearing   float64           `json:"bearing,omitempty"`  // in degrees, direction of the image
+	Accuracy  float64           `json:"accuracy,omitempty"` // in meters, horizontal positioning error
+	Inferred  bool              `json:"inferred,omitempty"` // coordinates interpolated from a track, not read from the file
 }
 
 func (g Geocode) HasAddress() bool {